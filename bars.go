@@ -0,0 +1,57 @@
+package gotdx
+
+import (
+	"context"
+	. "gotdx/imsg"
+	"sort"
+	"time"
+)
+
+// BarsRange 取股票、基金 category 种类(KLINE_TYPE_*)在 [from, to] 之间的K线, 按时间升序并去重.
+// from 为零值时取全部历史, to 为零值时不限制结束时间. 日线及以上的K线时间为当天 15:00,
+// 按日期比较, to 为当天 0 点时也包含当天的K线.
+// 从最新的K线开始每次取 MAX_KLINE_COUNT 根向前翻页, 直到早于 from 或服务器返回的数量不足一页.
+func BarsRange(ctx context.Context, hq ITdxHq, market uint16, code string, category uint16, from, to time.Time) ([]SecurityBarsElement, error) {
+	from, to = barDateRange(category, from, to)
+	seen := make(map[string]bool)
+	var bars []SecurityBarsElement
+	for start := 0; start <= 0xffff-MAX_KLINE_COUNT; start += MAX_KLINE_COUNT {
+		req := NewTDXSecurityBarsRequest(market, code, category, uint16(start), MAX_KLINE_COUNT)
+		rsp, err := hq.SecurityBars(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		for _, bar := range rsp.List {
+			if seen[bar.DateTime] {
+				continue
+			}
+			seen[bar.DateTime] = true
+			if t := bar.Time(); !t.Before(from) && (to.IsZero() || !t.After(to)) {
+				bars = append(bars, bar)
+			}
+		}
+		if len(rsp.List) < MAX_KLINE_COUNT || rsp.List[0].Time().Before(from) {
+			break
+		}
+	}
+	sort.Slice(bars, func(i, j int) bool { return bars[i].Time().Before(bars[j].Time()) })
+	return bars, nil
+}
+
+// barDateRange 日线及以上的K线按日期比较: from 取当天 0 点, to 取当天最后一刻, 零值保持不变
+func barDateRange(category uint16, from, to time.Time) (time.Time, time.Time) {
+	switch category {
+	case KLINE_TYPE_DAILY, KLINE_TYPE_WEEKLY, KLINE_TYPE_MONTHLY, KLINE_TYPE_RI_K, KLINE_TYPE_3MONTH, KLINE_TYPE_YEARLY:
+	default:
+		return from, to
+	}
+	if !from.IsZero() {
+		y, m, d := from.In(ChinaLocation).Date()
+		from = time.Date(y, m, d, 0, 0, 0, 0, ChinaLocation)
+	}
+	if !to.IsZero() {
+		y, m, d := to.In(ChinaLocation).Date()
+		to = time.Date(y, m, d+1, 0, 0, 0, 0, ChinaLocation).Add(-time.Nanosecond)
+	}
+	return from, to
+}
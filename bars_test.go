@@ -0,0 +1,138 @@
+package gotdx
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	. "gotdx/imsg"
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+// putPrice 按行情协议的变长格式写入有符号整数
+func putPrice(buf *bytes.Buffer, v int) {
+	sign := byte(0)
+	if v < 0 {
+		sign = 0x40
+		v = -v
+	}
+	b := byte(v&0x3f) | sign
+	v >>= 6
+	for v > 0 {
+		buf.WriteByte(b | 0x80)
+		b = byte(v & 0x7f)
+		v >>= 7
+	}
+	buf.WriteByte(b)
+}
+
+// dailyBars 模拟日K线, 第 i 根的日期为 2000-01-01 后第 i 天, 收盘价为 i/1000 元.
+// 每次请求后增加 grow 根新K线, 用于模拟翻页期间产生的新数据.
+type dailyBars struct {
+	mu   sync.Mutex
+	n    int
+	grow int
+	reqs int
+}
+
+func (d *dailyBars) handle(req []byte) []byte {
+	d.mu.Lock()
+	n := d.n
+	d.n += d.grow
+	d.reqs++
+	d.mu.Unlock()
+
+	start := int(binary.LittleEndian.Uint16(req[12:14]))
+	count := int(binary.LittleEndian.Uint16(req[14:16]))
+	hi := n - start
+	if hi < 0 {
+		hi = 0
+	}
+	lo := hi - count
+	if lo < 0 {
+		lo = 0
+	}
+	day0 := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, uint16(hi-lo))
+	last := 0
+	for i := lo; i < hi; i++ {
+		d := day0.AddDate(0, 0, i)
+		binary.Write(buf, binary.LittleEndian, uint32(d.Year()*10000+int(d.Month())*100+d.Day()))
+		putPrice(buf, i-last)
+		for j := 0; j < 3; j++ {
+			putPrice(buf, 0)
+		}
+		binary.Write(buf, binary.LittleEndian, math.Float32bits(100))
+		binary.Write(buf, binary.LittleEndian, math.Float32bits(1000))
+		last = i
+	}
+	return buf.Bytes()
+}
+
+func TestBarsRange(t *testing.T) {
+	hq, srv := newMockHq(t)
+	bars := &dailyBars{n: 3000, grow: 1}
+	srv.Handle(KMSG_INDEXBARS, bars.handle)
+
+	all, err := BarsRange(context.Background(), hq, MARKET_SH, "600000", KLINE_TYPE_DAILY, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 翻页期间新增的K线使相邻两页重叠, 结果仍应连续且不重复
+	for i := 1; i < len(all); i++ {
+		if !all[i].Time().After(all[i-1].Time()) {
+			t.Fatalf("bar %d (%s) not after bar %d (%s)", i, all[i].DateTime, i-1, all[i-1].DateTime)
+		}
+		if all[i].Time().Sub(all[i-1].Time()) != 24*time.Hour {
+			t.Fatalf("gap between %s and %s", all[i-1].DateTime, all[i].DateTime)
+		}
+	}
+	if all[0].DateTime != "2000-01-01 15:00:00" || all[0].Close != 0 {
+		t.Errorf("first bar = %+v", all[0])
+	}
+	if len(all) < 3000 {
+		t.Errorf("got %d bars, want at least 3000", len(all))
+	}
+}
+
+func TestBarsRange_Between(t *testing.T) {
+	hq, srv := newMockHq(t)
+	bars := &dailyBars{n: 3000}
+	srv.Handle(KMSG_INDEXBARS, bars.handle)
+
+	from := time.Date(2007, 1, 1, 0, 0, 0, 0, ChinaLocation)
+	to := time.Date(2007, 1, 10, 23, 59, 0, 0, ChinaLocation)
+	got, err := BarsRange(context.Background(), hq, MARKET_SH, "600000", KLINE_TYPE_DAILY, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 10 || got[0].DateTime != "2007-01-01 15:00:00" || got[9].DateTime != "2007-01-10 15:00:00" {
+		t.Fatalf("got %d bars", len(got))
+	}
+	// 2007-01-01 是第 2557 根, 距最新的K线 443 根, 第一页即可覆盖
+	if bars.reqs != 1 {
+		t.Errorf("sent %d requests, want 1", bars.reqs)
+	}
+	if got[0].Close != 2.557 {
+		t.Errorf("Close = %v, want 2.557", got[0].Close)
+	}
+}
+
+func TestBarsRange_MidnightTo(t *testing.T) {
+	hq, srv := newMockHq(t)
+	srv.Handle(KMSG_INDEXBARS, (&dailyBars{n: 3000}).handle)
+
+	// 日K线时间为 15:00, 按日期比较时 0 点的 to 也包含当天
+	from := time.Date(2007, 1, 1, 10, 0, 0, 0, ChinaLocation)
+	to := time.Date(2007, 1, 10, 0, 0, 0, 0, ChinaLocation)
+	got, err := BarsRange(context.Background(), hq, MARKET_SH, "600000", KLINE_TYPE_DAILY, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 10 || got[0].DateTime != "2007-01-01 15:00:00" || got[9].DateTime != "2007-01-10 15:00:00" {
+		t.Fatalf("got %d bars", len(got))
+	}
+}
@@ -0,0 +1,55 @@
+package gotdx
+
+import (
+	"context"
+	"sync"
+)
+
+// fanOut 以最多 limit 个并发调用 fn(ctx, i), i 取 0 到 n-1.
+// 任一调用失败时取消其余调用并返回第一个错误; ctx 结束后不再发起新的调用, 返回 ctx.Err().
+// fn 可能并发执行, 写入共享结果时需自行加锁.
+func fanOut(ctx context.Context, n, limit int, fn func(ctx context.Context, i int) error) error {
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+		sem      = make(chan struct{}, limit)
+	)
+	launched := 0
+	for ; launched < n; launched++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := fn(ctx, i); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				mu.Unlock()
+			}
+		}(launched)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	if launched < n {
+		return parent.Err()
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package gotdx
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFanOut_StopsOnCancel(t *testing.T) {
+	// 只有一个并发名额, 第一个调用取消 ctx 后不应再发起新的调用
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calls := 0
+	err := fanOut(ctx, 3, 1, func(ctx context.Context, i int) error {
+		calls++
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if calls != 1 {
+		t.Fatalf("%d calls after cancel, want 1", calls)
+	}
+}
+
+func TestFanOut_FirstError(t *testing.T) {
+	boom := errors.New("boom")
+	err := fanOut(context.Background(), 5, 2, func(ctx context.Context, i int) error {
+		if i == 2 {
+			return boom
+		}
+		return nil
+	})
+	if err != boom {
+		t.Fatalf("err = %v, want %v", err, boom)
+	}
+}
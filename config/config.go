@@ -49,7 +49,6 @@ func (c *TDXConfig) Load() error {
 			}
 		}
 	}
-	return nil
 }
 
 func (c *TDXConfig) Remoter(tag string) (m map[string]string) {
@@ -1,9 +1,12 @@
 package gotdx
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"hash/fnv"
+	. "gotdx/imsg"
+	"net"
 	"os"
 	"reflect"
 	"runtime"
@@ -17,6 +20,92 @@ func (e ErrUndefined) Error() string {
 	return fmt.Sprintf("undefined message type %d", e)
 }
 
+// ErrTimeout is returned when a request misses its deadline.
+type ErrTimeout struct {
+	Err error
+}
+
+func (e ErrTimeout) Error() string {
+	return fmt.Sprintf("request timeout: %v", e.Err)
+}
+
+func (e ErrTimeout) Unwrap() error {
+	return e.Err
+}
+
+// Timeout reports true, so ErrTimeout satisfies net.Error style checks.
+func (e ErrTimeout) Timeout() bool {
+	return true
+}
+
+// ErrConnReset is returned when the connection breaks while a request is in flight.
+type ErrConnReset struct {
+	Err error
+}
+
+func (e ErrConnReset) Error() string {
+	return fmt.Sprintf("connection reset: %v", e.Err)
+}
+
+func (e ErrConnReset) Unwrap() error {
+	return e.Err
+}
+
+// ErrDecode is returned when a response body cannot be decoded.
+type ErrDecode struct {
+	Type int32
+	Err  error
+}
+
+func (e ErrDecode) Error() string {
+	return fmt.Sprintf("decode message type %d: %v", e.Type, e.Err)
+}
+
+func (e ErrDecode) Unwrap() error {
+	return e.Err
+}
+
+// ErrUnexpectedMessage is returned when the response type does not match the request.
+type ErrUnexpectedMessage struct {
+	Want int32
+	Got  int32
+}
+
+func (e ErrUnexpectedMessage) Error() string {
+	return fmt.Sprintf("unexpected message type %d, want %d", e.Got, e.Want)
+}
+
+func unexpectedMessage(want int32, msg Message) error {
+	got := int32(-1)
+	if msg != nil {
+		got = msg.MessageNumber()
+	}
+	return ErrUnexpectedMessage{Want: want, Got: got}
+}
+
+// connError classifies a transport error into ErrTimeout or ErrConnReset,
+// a cancelled ctx is returned as is.
+func connError(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return ctx.Err()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrTimeout{Err: err}
+	}
+	if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		return ErrTimeout{Err: err}
+	}
+	return ErrConnReset{Err: err}
+}
+
+// IsRetryable reports whether err is a timeout or connection failure,
+// i.e. the same request may succeed on a fresh connection.
+func IsRetryable(err error) bool {
+	var timeout ErrTimeout
+	var reset ErrConnReset
+	return errors.As(err, &timeout) || errors.As(err, &reset)
+}
+
 // Error codes returned by failures dealing with server or connection.
 var (
 	ErrParameter     = errors.New("parameter error")
@@ -28,6 +117,7 @@ var (
 	ErrBadData       = errors.New("more than 8M data")
 	ErrNotRegistered = errors.New("handler not registered")
 	ErrServerClosed  = errors.New("server has been closed")
+	ErrClientClosed  = errors.New("client has been closed")
 )
 
 // definitions about some constants.
@@ -0,0 +1,41 @@
+package gotdx
+
+import (
+	"context"
+	. "gotdx/imsg"
+	"sort"
+	"time"
+)
+
+// ExBarsBetween 取拓展市场品种 category 种类(KLINE_TYPE_*)在 [from, to] 之间的全部K线, 按时间升序并去重.
+// to 为零值时不限制结束时间. 日线及以上的K线时间为当天 15:00, 按日期比较, to 为当天 0 点时也包含当天的K线.
+// 从最新的K线开始每次取 MAX_KLINE_COUNT 根向前翻页, 直到早于 from 或服务器没有更多数据,
+// 翻页期间新增的K线会使相邻两页重叠, 按时间去重.
+func ExBarsBetween(ctx context.Context, hq ITdxEXHq, market uint8, code string, category uint16, from, to time.Time) ([]ExInstrumentBarsElement, error) {
+	from, to = barDateRange(category, from, to)
+	seen := make(map[string]bool)
+	var bars []ExInstrumentBarsElement
+	for start := uint32(0); ; start += MAX_KLINE_COUNT {
+		rsp, err := hq.InstrumentBars(ctx, NewTDXExInstrumentBarsRequest(market, code, category, start, MAX_KLINE_COUNT))
+		if err != nil {
+			return nil, err
+		}
+		if len(rsp.List) == 0 {
+			break
+		}
+		for _, bar := range rsp.List {
+			if seen[bar.DateTime] {
+				continue
+			}
+			seen[bar.DateTime] = true
+			if t := bar.Time(); !t.Before(from) && (to.IsZero() || !t.After(to)) {
+				bars = append(bars, bar)
+			}
+		}
+		if len(rsp.List) < MAX_KLINE_COUNT || rsp.List[0].Time().Before(from) {
+			break
+		}
+	}
+	sort.Slice(bars, func(i, j int) bool { return bars[i].Time().Before(bars[j].Time()) })
+	return bars, nil
+}
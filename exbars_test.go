@@ -0,0 +1,130 @@
+package gotdx
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	. "gotdx/imsg"
+	"math"
+	"testing"
+	"time"
+)
+
+// exDailyBars 模拟 n 根日K线, 第 i 根的日期为 2010-01-01 后第 i 天, 收盘价为 i
+func exDailyBars(n int) func(req []byte) []byte {
+	day0 := time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)
+	return func(req []byte) []byte {
+		start := int(binary.LittleEndian.Uint32(req[14:18]))
+		count := int(binary.LittleEndian.Uint16(req[18:20]))
+		hi := n - start
+		lo := hi - count
+		if lo < 0 {
+			lo = 0
+		}
+		if hi < lo {
+			hi = lo
+		}
+		buf := new(bytes.Buffer)
+		buf.Write(req[:18])
+		binary.Write(buf, binary.LittleEndian, uint16(hi-lo))
+		for i := lo; i < hi; i++ {
+			d := day0.AddDate(0, 0, i)
+			binary.Write(buf, binary.LittleEndian, uint32(d.Year()*10000+int(d.Month())*100+d.Day()))
+			c := math.Float32bits(float32(i))
+			binary.Write(buf, binary.LittleEndian, []uint32{c, c, c, c, 0, 1, c})
+		}
+		return buf.Bytes()
+	}
+}
+
+func TestExBarsBetween(t *testing.T) {
+	hq, srv := newMockExHq(t)
+	var requests int
+	bars := exDailyBars(2000)
+	srv.Handle(KMSG_EXINSTRUMENTBARS, func(req []byte) []byte {
+		requests++
+		return bars(req)
+	})
+
+	from := time.Date(2011, 1, 1, 0, 0, 0, 0, ChinaLocation)
+	to := time.Date(2011, 1, 31, 23, 59, 0, 0, ChinaLocation)
+	got, err := ExBarsBetween(context.Background(), hq, 30, "RBL8", KLINE_TYPE_DAILY, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 31 {
+		t.Fatalf("got %d bars, want 31", len(got))
+	}
+	for i, bar := range got {
+		if bar.Year != 2011 || bar.Month != 1 || bar.Day != i+1 {
+			t.Fatalf("bar %d is %s", i, bar.DateTime)
+		}
+	}
+	// 2011-01-01 是第 365 根, 需要翻到第 3 页
+	if requests != 3 {
+		t.Errorf("sent %d requests, want 3", requests)
+	}
+
+	all, err := ExBarsBetween(context.Background(), hq, 30, "RBL8", KLINE_TYPE_DAILY, time.Time{}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2000 || all[0].Close != 0 || all[1999].Close != 1999 {
+		t.Errorf("got %d bars from %v to %v", len(all), all[0].Close, all[len(all)-1].Close)
+	}
+}
+
+func TestExBarsBetween_Overlap(t *testing.T) {
+	hq, srv := newMockExHq(t)
+	// 每次请求后新增一根K线, 相邻两页重叠一根
+	n := 2000
+	srv.Handle(KMSG_EXINSTRUMENTBARS, func(req []byte) []byte {
+		b := exDailyBars(n)(req)
+		n++
+		return b
+	})
+
+	// 日K线时间为 15:00, 按日期比较时 0 点的 to 也包含当天
+	to := time.Date(2015, 6, 20, 0, 0, 0, 0, ChinaLocation)
+	got, err := ExBarsBetween(context.Background(), hq, 30, "RBL8", KLINE_TYPE_DAILY, time.Time{}, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i].Time().Sub(got[i-1].Time()) != 24*time.Hour {
+			t.Fatalf("bar %s follows %s", got[i].DateTime, got[i-1].DateTime)
+		}
+	}
+	if len(got) != 1997 || got[len(got)-1].DateTime != "2015-06-20 15:00:00" {
+		t.Errorf("got %d bars ending %s", len(got), got[len(got)-1].DateTime)
+	}
+}
+
+func TestTdxExHq_HistoryInstrumentBarsRange(t *testing.T) {
+	hq, srv := newMockExHq(t)
+	srv.Handle(KMSG_EXHISTORYINSTRUMENTBARSRANGE, func(req []byte) []byte {
+		if start, end := binary.LittleEndian.Uint32(req[10:14]), binary.LittleEndian.Uint32(req[14:18]); start != 20210104 || end != 20210105 {
+			t.Errorf("range %d-%d", start, end)
+		}
+		buf := new(bytes.Buffer)
+		buf.Write(make([]byte, 12))
+		binary.Write(buf, binary.LittleEndian, uint16(1))
+		binary.Write(buf, binary.LittleEndian, uint16((2021-2004)<<11+104))
+		binary.Write(buf, binary.LittleEndian, uint16(21*60+5))
+		f := math.Float32bits
+		binary.Write(buf, binary.LittleEndian, []uint32{f(4000), f(4010), f(3990), f(4005), 180000, 250, f(4001)})
+		return buf.Bytes()
+	})
+
+	rsp, err := hq.HistoryInstrumentBarsRange(context.Background(), NewTDXExHistoryInstrumentBarsRangeRequest(30, "RBL8", 20210104, 20210105))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rsp.List) != 1 {
+		t.Fatalf("got %d bars, want 1", len(rsp.List))
+	}
+	bar := rsp.List[0]
+	if bar.DateTime != "2021-01-04 21:05:00" || bar.Close != 4005 || bar.Position != 180000 || bar.Price != 4001 {
+		t.Errorf("bar = %+v", bar)
+	}
+}
@@ -0,0 +1,83 @@
+package gotdx
+
+import (
+	"context"
+	. "gotdx/imsg"
+	"sort"
+)
+
+const EX_INSTRUMENT_INFO_PAGE = 500 // 每次请求的品种数量
+
+// ExInstrumentKey 拓展市场品种键
+type ExInstrumentKey struct {
+	Market uint8
+	Code   string
+}
+
+// ExCatalogue 拓展行情服务器提供的市场和全部品种
+type ExCatalogue struct {
+	Markets     map[uint8]ExMarketElement
+	Instruments map[ExInstrumentKey]ExInstrumentInfoElement
+}
+
+// LoadExCatalogue 取市场列表并按 InstrumentCount 分页取全部品种信息
+func LoadExCatalogue(ctx context.Context, hq ITdxEXHq) (*ExCatalogue, error) {
+	markets, err := hq.Markets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	instruments, err := ExInstrumentInfoAll(ctx, hq)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &ExCatalogue{
+		Markets:     make(map[uint8]ExMarketElement, len(markets.List)),
+		Instruments: make(map[ExInstrumentKey]ExInstrumentInfoElement, len(instruments)),
+	}
+	for _, m := range markets.List {
+		c.Markets[m.Market] = m
+	}
+	for _, ins := range instruments {
+		c.Instruments[ExInstrumentKey{Market: ins.Market, Code: ins.Code}] = ins
+	}
+	return c, nil
+}
+
+// ExInstrumentInfoAll 分页取全部品种信息
+func ExInstrumentInfoAll(ctx context.Context, hq ITdxEXHq) ([]ExInstrumentInfoElement, error) {
+	count, err := hq.InstrumentCount(ctx)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]ExInstrumentInfoElement, 0, count.Count)
+	for start := uint32(0); start < count.Count; start += EX_INSTRUMENT_INFO_PAGE {
+		rsp, err := hq.InstrumentInfo(ctx, TDXExInstrumentInfoRequest{Start: start, Count: EX_INSTRUMENT_INFO_PAGE})
+		if err != nil {
+			return nil, err
+		}
+		if len(rsp.List) == 0 {
+			break
+		}
+		list = append(list, rsp.List...)
+	}
+	return list, nil
+}
+
+// Instrument 查找品种
+func (c *ExCatalogue) Instrument(market uint8, code string) (ExInstrumentInfoElement, bool) {
+	ins, ok := c.Instruments[ExInstrumentKey{Market: market, Code: code}]
+	return ins, ok
+}
+
+// InstrumentsOf 市场 market 的全部品种, 按代码排序
+func (c *ExCatalogue) InstrumentsOf(market uint8) []ExInstrumentInfoElement {
+	var list []ExInstrumentInfoElement
+	for key, ins := range c.Instruments {
+		if key.Market == market {
+			list = append(list, ins)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Code < list[j].Code })
+	return list
+}
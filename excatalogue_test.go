@@ -0,0 +1,70 @@
+package gotdx
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	. "gotdx/imsg"
+	"testing"
+)
+
+func TestLoadExCatalogue(t *testing.T) {
+	hq, srv := newMockExHq(t)
+	const total = 1234
+	srv.Handle(KMSG_EXMARKETS, func([]byte) []byte {
+		buf := new(bytes.Buffer)
+		binary.Write(buf, binary.LittleEndian, uint16(2))
+		for _, market := range []byte{30, 31} {
+			var rec [62]byte
+			rec[0] = EX_CATEGORY_FUTURES
+			copy(rec[1:33], fmt.Sprintf("M%d", market))
+			rec[33] = market
+			buf.Write(rec[:])
+		}
+		return buf.Bytes()
+	})
+	srv.Handle(KMSG_EXINSTRUMENTCOUNT, func([]byte) []byte {
+		b := make([]byte, 23)
+		binary.LittleEndian.PutUint32(b[19:], total)
+		return b
+	})
+	// 第 i 个品种在市场 30+i%2, 代码为 C<i>
+	srv.Handle(KMSG_EXINSTRUMENTINFO, func(req []byte) []byte {
+		start := binary.LittleEndian.Uint32(req[0:4])
+		count := uint32(binary.LittleEndian.Uint16(req[4:6]))
+		if start+count > total {
+			count = total - start
+		}
+		buf := new(bytes.Buffer)
+		binary.Write(buf, binary.LittleEndian, start)
+		binary.Write(buf, binary.LittleEndian, uint16(count))
+		for i := start; i < start+count; i++ {
+			var rec [64]byte
+			rec[0], rec[1] = EX_CATEGORY_FUTURES, byte(30+i%2)
+			copy(rec[5:14], fmt.Sprintf("C%d", i))
+			buf.Write(rec[:])
+		}
+		return buf.Bytes()
+	})
+
+	c, err := LoadExCatalogue(context.Background(), hq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Markets) != 2 || c.Markets[31].Name != "M31" {
+		t.Errorf("markets = %+v", c.Markets)
+	}
+	if len(c.Instruments) != total {
+		t.Fatalf("got %d instruments, want %d", len(c.Instruments), total)
+	}
+	if ins, ok := c.Instrument(31, "C1233"); !ok || ins.Category != EX_CATEGORY_FUTURES {
+		t.Errorf("Instrument(31, C1233) = %+v, %v", ins, ok)
+	}
+	if _, ok := c.Instrument(30, "C1233"); ok {
+		t.Error("C1233 should not be listed on market 30")
+	}
+	if list := c.InstrumentsOf(30); len(list) != total/2 || list[0].Code != "C0" {
+		t.Errorf("InstrumentsOf(30) returned %d instruments", len(list))
+	}
+}
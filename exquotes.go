@@ -0,0 +1,31 @@
+package gotdx
+
+import (
+	"context"
+	. "gotdx/imsg"
+	"math"
+)
+
+// ExQuoteListAll 分页取拓展市场 market 的全部行情, category 为市场类别 EX_CATEGORY_*.
+// 起始位置超过 uint16 范围或服务器重复返回同一页时停止.
+func ExQuoteListAll(ctx context.Context, hq ITdxEXHq, market uint8, category uint8) ([]ExInstrumentQuoteListElement, error) {
+	var quotes []ExInstrumentQuoteListElement
+	var lastFirst string
+	for start := 0; start <= math.MaxUint16; start += EX_QUOTE_LIST_PAGE {
+		rsp, err := hq.InstrumentQuoteList(ctx, NewTDXExInstrumentQuoteListRequest(market, category, uint16(start), EX_QUOTE_LIST_PAGE))
+		if err != nil {
+			return nil, err
+		}
+		if len(rsp.List) > 0 {
+			if start > 0 && rsp.List[0].Code == lastFirst {
+				break
+			}
+			lastFirst = rsp.List[0].Code
+		}
+		quotes = append(quotes, rsp.List...)
+		if len(rsp.List) < EX_QUOTE_LIST_PAGE {
+			break
+		}
+	}
+	return quotes, nil
+}
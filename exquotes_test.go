@@ -0,0 +1,89 @@
+package gotdx
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	. "gotdx/imsg"
+	"math"
+	"testing"
+)
+
+// exQuoteList 模拟 n 个期货品种的行情列表, 第 i 个品种代码为 F<i>, 最新价为 i
+func exQuoteList(n int) func(req []byte) []byte {
+	return func(req []byte) []byte {
+		start := int(binary.LittleEndian.Uint16(req[3:5]))
+		count := int(binary.LittleEndian.Uint16(req[5:7]))
+		end := start + count
+		if end > n {
+			end = n
+		}
+		if start > end {
+			start = end
+		}
+		buf := new(bytes.Buffer)
+		binary.Write(buf, binary.LittleEndian, uint16(end-start))
+		for i := start; i < end; i++ {
+			rec := make([]byte, 314)
+			rec[0] = req[0]
+			copy(rec[1:10], fmt.Sprintf("F%d", i))
+			binary.LittleEndian.PutUint32(rec[14+16:], math.Float32bits(float32(i)))
+			binary.LittleEndian.PutUint32(rec[14+20:], 7)
+			rec[313] = 0xee
+			buf.Write(rec)
+		}
+		return buf.Bytes()
+	}
+}
+
+func TestExQuoteListAll(t *testing.T) {
+	hq, srv := newMockExHq(t)
+	srv.Handle(KMSG_EXINSTRUMENTQUOTELIST, exQuoteList(200))
+
+	quotes, err := ExQuoteListAll(context.Background(), hq, 30, EX_CATEGORY_FUTURES)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quotes) != 200 {
+		t.Fatalf("got %d quotes, want 200", len(quotes))
+	}
+	for i, q := range quotes {
+		if q.Market != 30 || q.Code != fmt.Sprintf("F%d", i) || q.Price != float64(i) || q.KaiCang != 7 {
+			t.Fatalf("quote %d = %+v", i, q.ExInstrumentQuoteElement)
+		}
+		if len(q.Extra) != 314-150 || q.Extra[len(q.Extra)-1] != 0xee {
+			t.Fatalf("quote %d extra %d bytes", i, len(q.Extra))
+		}
+	}
+}
+
+func TestTdxExHq_InstrumentQuoteListCategory(t *testing.T) {
+	hq, srv := newMockExHq(t)
+	srv.Handle(KMSG_EXINSTRUMENTQUOTELIST, exQuoteList(1))
+
+	if _, err := hq.InstrumentQuoteList(context.Background(), NewTDXExInstrumentQuoteListRequest(30, 9, 0, 1)); err == nil {
+		t.Error("expected error for unsupported category")
+	}
+}
+
+func TestExQuoteListAll_RepeatedPage(t *testing.T) {
+	hq, srv := newMockExHq(t)
+	// 服务器忽略起始位置, 每次都返回同一整页
+	page := exQuoteList(EX_QUOTE_LIST_PAGE * 2)
+	var requests int
+	srv.Handle(KMSG_EXINSTRUMENTQUOTELIST, func(req []byte) []byte {
+		requests++
+		req = append([]byte(nil), req...)
+		binary.LittleEndian.PutUint16(req[3:5], 0)
+		return page(req)
+	})
+
+	quotes, err := ExQuoteListAll(context.Background(), hq, 30, EX_CATEGORY_FUTURES)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quotes) != EX_QUOTE_LIST_PAGE || requests != 2 {
+		t.Errorf("got %d quotes in %d requests, want %d in 2", len(quotes), requests, EX_QUOTE_LIST_PAGE)
+	}
+}
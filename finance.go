@@ -0,0 +1,32 @@
+package gotdx
+
+import (
+	"context"
+	. "gotdx/imsg"
+	"sync"
+)
+
+const FINANCE_BATCH_CONCURRENCY = 8 // 批量查询财务信息的并发请求数
+
+// FinanceInfos 查询多只股票的财务信息, 市场由代码推断, 结果以代码为键.
+// 请求并发发出, 任一请求失败时取消其余请求并返回该错误.
+func FinanceInfos(ctx context.Context, hq ITdxHq, codes []string) (map[string]TDXFinanceInfoResponse, error) {
+	var mu sync.Mutex
+	result := make(map[string]TDXFinanceInfoResponse, len(codes))
+	err := fanOut(ctx, len(codes), FINANCE_BATCH_CONCURRENCY, func(ctx context.Context, i int) error {
+		req := TDXFinanceInfoRequest{Market: MarketOf(codes[i])}
+		copy(req.Code[:], codes[i])
+		rsp, err := hq.FinanceInfo(ctx, req)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		result[codes[i]] = rsp
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,56 @@
+package gotdx
+
+import (
+	"context"
+	"encoding/binary"
+	. "gotdx/imsg"
+	"math"
+	"testing"
+)
+
+func TestFinanceInfos(t *testing.T) {
+	hq, srv := newMockHq(t)
+	// 按请求中的代码回复, 流通股本为代码的数值(万股)
+	srv.Handle(KMSG_FINANCEINFO, func(req []byte) []byte {
+		body := make([]byte, 2+7+136)
+		copy(body[2:9], req[2:9])
+		var n float64
+		for _, c := range req[3:9] {
+			n = n*10 + float64(c-'0')
+		}
+		binary.LittleEndian.PutUint32(body[9:], math.Float32bits(float32(n)))
+		return body
+	})
+
+	codes := []string{"600004", "000001", "300750", "688001"}
+	result, err := FinanceInfos(context.Background(), hq, codes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != len(codes) {
+		t.Fatalf("got %d results, want %d", len(result), len(codes))
+	}
+	for _, code := range codes {
+		rsp := result[code]
+		if string(rsp.Code[:]) != code {
+			t.Errorf("%s: code %s", code, rsp.Code)
+		}
+		if rsp.Market != MarketOf(code) {
+			t.Errorf("%s: market %d", code, rsp.Market)
+		}
+	}
+	if got := result["000001"].Ltgb; got != 10000 {
+		t.Errorf("000001 Ltgb = %v, want 10000", got)
+	}
+}
+
+func TestMarketOf(t *testing.T) {
+	for code, want := range map[string]uint8{
+		"600000": MARKET_SH, "688001": MARKET_SH, "510300": MARKET_SH, "900901": MARKET_SH,
+		"000001": MARKET_SZ, "300750": MARKET_SZ, "159919": MARKET_SZ, "200002": MARKET_SZ,
+	} {
+		if got := MarketOf(code); got != want {
+			t.Errorf("MarketOf(%s) = %d, want %d", code, got, want)
+		}
+	}
+}
@@ -3,7 +3,7 @@ module gotdx
 go 1.16
 
 require (
-	github.com/axgle/mahonia v0.0.0-20180208002826-3358181d7394 // indirect
-	go.uber.org/atomic v1.9.0 // indirect
-	golang.org/x/net v0.0.0-20210716203947-853a461950ff // indirect
+	github.com/axgle/mahonia v0.0.0-20180208002826-3358181d7394
+	go.uber.org/atomic v1.9.0
+	golang.org/x/net v0.0.0-20210716203947-853a461950ff
 )
@@ -3,6 +3,7 @@ package imsg
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 )
 
 //TDXBlockInfoMetaRequest 板块请求
@@ -28,11 +29,7 @@ type TDXBlockInfoMetaMessage struct {
 
 // NewTDXBlockInfoMetaMessage 创建板块消息
 func NewTDXBlockInfoMetaMessage(req TDXBlockInfoMetaRequest) *TDXBlockInfoMetaMessage {
-	msg := GetMessage(KMSG_BLOCKINFOMETA)
-	if msg == nil {
-		Register(KMSG_BLOCKINFOMETA, new(TDXBlockInfoMetaMessage))
-	}
-	sub := GetMessage(KMSG_BLOCKINFOMETA).(*TDXBlockInfoMetaMessage)
+	sub := new(TDXBlockInfoMetaMessage)
 	sub.TDXBlockInfoMetaRequest = req
 	sub.TDXReqHeader = TDXReqHeader{0x0c, SeqID(), 0, 0x2a, 0x2a, KMSG_BLOCKINFOMETA}
 	return sub
@@ -82,11 +79,7 @@ type TDXBlockInfoMessage struct {
 }
 
 func NewTDXBlockInfoMessage(req TDXBlockInfoRequest) *TDXBlockInfoMessage {
-	msg := GetMessage(KMSG_BLOCKINFO)
-	if msg == nil {
-		Register(KMSG_BLOCKINFO, new(TDXBlockInfoMessage))
-	}
-	sub := GetMessage(KMSG_BLOCKINFO).(*TDXBlockInfoMessage)
+	sub := new(TDXBlockInfoMessage)
 	sub.TDXBlockInfoRequest = req
 	sub.TDXReqHeader = TDXReqHeader{0x0c, SeqID(), 0, 0x6e, 0x6e, KMSG_BLOCKINFO}
 	return sub
@@ -109,3 +102,46 @@ func (c *TDXBlockInfoMessage) UnSerialize(header interface{}, b []byte) error {
 	c.TDXRespHeader = h
 	return nil
 }
+
+const (
+	BLOCK_FILE_HEADER_SIZE = 384  // 板块文件头字节数
+	BLOCK_RECORD_SIZE      = 2813 // 每个板块的字节数: 名称9 个数2 类别2 代码7*400
+	BLOCK_MAX_STOCKS       = 400  // 每个板块最多的成员数
+)
+
+// DecodeBlockFile 解析 block.dat、block_gn.dat 等板块文件, 网络下载和本地 T0002/hq_cache 的文件格式相同.
+// 参见 http://blog.csdn.net/Metal1/article/details/44352639
+func DecodeBlockFile(b []byte) (TDXBlockInfoResponse, error) {
+	resp := TDXBlockInfoResponse{}
+	pos := BLOCK_FILE_HEADER_SIZE
+	if len(b) < pos+2 {
+		return resp, fmt.Errorf("block file: header truncated, %d bytes", len(b))
+	}
+	resp.BlockNum = binary.LittleEndian.Uint16(b[pos:])
+	pos += 2
+	resp.Block = make([]BlockInfo, 0, resp.BlockNum)
+	for index := uint16(0); index < resp.BlockNum; index++ {
+		begin := pos
+		if len(b) < pos+13 {
+			return resp, fmt.Errorf("block file: block %d of %d truncated", index, resp.BlockNum)
+		}
+		bi := BlockInfo{}
+		bi.Blockname = getgbkstr(b[pos : pos+9])
+		pos += 9
+		bi.Stockcount = binary.LittleEndian.Uint16(b[pos:])
+		pos += 2
+		bi.Blocktype = binary.LittleEndian.Uint16(b[pos:])
+		pos += 2
+		if bi.Stockcount > BLOCK_MAX_STOCKS || len(b) < pos+int(bi.Stockcount)*7 {
+			return resp, fmt.Errorf("block file: block %s has %d stocks, data truncated", bi.Blockname, bi.Stockcount)
+		}
+		bi.Codelist = make([]string, 0, bi.Stockcount)
+		for i := uint16(0); i < bi.Stockcount; i++ {
+			bi.Codelist = append(bi.Codelist, getgbkstr(b[pos:pos+7]))
+			pos += 7
+		}
+		resp.Block = append(resp.Block, bi)
+		pos = begin + BLOCK_RECORD_SIZE
+	}
+	return resp, nil
+}
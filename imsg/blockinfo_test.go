@@ -0,0 +1,61 @@
+package imsg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/axgle/mahonia"
+	"testing"
+)
+
+// blockFile 构造板块文件, blocks 为板块名称到成员代码
+func blockFile(names []string, codes [][]string) []byte {
+	buf := new(bytes.Buffer)
+	buf.Write(make([]byte, BLOCK_FILE_HEADER_SIZE))
+	binary.Write(buf, binary.LittleEndian, uint16(len(names)))
+	enc := mahonia.NewEncoder("gbk")
+	for i, name := range names {
+		rec := make([]byte, BLOCK_RECORD_SIZE)
+		copy(rec, enc.ConvertString(name))
+		binary.LittleEndian.PutUint16(rec[9:], uint16(len(codes[i])))
+		binary.LittleEndian.PutUint16(rec[11:], 2)
+		for j, code := range codes[i] {
+			copy(rec[13+j*7:], code)
+		}
+		buf.Write(rec)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeBlockFile(t *testing.T) {
+	b := blockFile([]string{"银行", "白酒"}, [][]string{{"600000", "000001"}, {"600519"}})
+	rsp, err := DecodeBlockFile(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rsp.BlockNum != 2 || len(rsp.Block) != 2 {
+		t.Fatalf("got %+v", rsp)
+	}
+	bank := rsp.Block[0]
+	if bank.Blockname != "银行" || bank.Blocktype != 2 || bank.Stockcount != 2 ||
+		len(bank.Codelist) != 2 || bank.Codelist[1] != "000001" {
+		t.Errorf("block 0 = %+v", bank)
+	}
+	if rsp.Block[1].Blockname != "白酒" || rsp.Block[1].Codelist[0] != "600519" {
+		t.Errorf("block 1 = %+v", rsp.Block[1])
+	}
+
+	// 最后一个板块只需包含实际的成员
+	if _, err := DecodeBlockFile(b[:len(b)-BLOCK_RECORD_SIZE+13+7]); err != nil {
+		t.Errorf("short last block: %v", err)
+	}
+	for _, n := range []int{0, 100, BLOCK_FILE_HEADER_SIZE + 10, len(b) - BLOCK_RECORD_SIZE + 15} {
+		if _, err := DecodeBlockFile(b[:n]); err == nil {
+			t.Errorf("%d bytes: want error", n)
+		}
+	}
+	bad := blockFile([]string{"坏"}, [][]string{nil})
+	binary.LittleEndian.PutUint16(bad[BLOCK_FILE_HEADER_SIZE+2+9:], BLOCK_MAX_STOCKS+1)
+	if _, err := DecodeBlockFile(bad); err == nil {
+		t.Error("too many stocks: want error")
+	}
+}
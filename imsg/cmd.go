@@ -4,11 +4,7 @@ import "encoding/hex"
 
 // NewCMD1Message 创建登录消息1
 func NewCMD1Message() *CMD1Message {
-	msg := GetMessage(KMSG_CMD1)
-	if msg == nil {
-		Register(KMSG_CMD1, new(CMD1Message))
-	}
-	sub := GetMessage(KMSG_CMD1).(*CMD1Message)
+	sub := new(CMD1Message)
 	sub.Content = "0c0218930001030003000d0001"
 	return sub
 }
@@ -36,11 +32,7 @@ func (c *CMD1Message) UnSerialize(header interface{}, b []byte) error {
 
 // NewCMD2Message 创建登录消息2
 func NewCMD2Message() *CMD2Message {
-	msg := GetMessage(KMSG_CMD2)
-	if msg == nil {
-		Register(KMSG_CMD2, new(CMD2Message))
-	}
-	sub := GetMessage(KMSG_CMD2).(*CMD2Message)
+	sub := new(CMD2Message)
 	sub.Content = "0c031899000120002000db0fd5d0c9ccd6a4a8af0000008fc22540130000d500c9ccbdf0d7ea00000002"
 	return sub
 }
@@ -63,11 +55,7 @@ func (c *CMD2Message) UnSerialize(header interface{}, b []byte) error {
 }
 
 func NewPingMessage() *PingMessage {
-	msg := GetMessage(KMSG_PING)
-	if msg == nil {
-		Register(KMSG_PING, new(PingMessage))
-	}
-	sub := GetMessage(KMSG_PING).(*PingMessage)
+	sub := new(PingMessage)
 	sub.Content = "0c0000000000020002001500"
 	return sub
 }
@@ -3,6 +3,7 @@ package imsg
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"github.com/axgle/mahonia"
 )
 
@@ -11,6 +12,13 @@ type TDXCompanyInfoCategoryRequest struct {
 	Code   [6]byte
 }
 
+func NewTDXCompanyInfoCategoryRequest(market uint16, code string) TDXCompanyInfoCategoryRequest {
+	req := TDXCompanyInfoCategoryRequest{Market: market}
+	copy(req.Code[:], code)
+	return req
+}
+
+// CompanyInfoCategory F10 目录项, 内容位于 FileName 文件 Start 起的 Interval 个字节
 type CompanyInfoCategory struct {
 	Name     string
 	FileName string
@@ -31,22 +39,18 @@ type TDXCompanyInfoCategoryMessage struct {
 }
 
 func NewTDXCompanyInfoCategoryMessage(req TDXCompanyInfoCategoryRequest) *TDXCompanyInfoCategoryMessage {
-	msg := GetMessage(KMSG_COMPANYCATEGORY)
-	if (msg == nil) {
-		Register(KMSG_COMPANYCATEGORY, new(TDXCompanyInfoCategoryMessage))
-	}
-	sub := GetMessage(KMSG_COMPANYCATEGORY).(*TDXCompanyInfoCategoryMessage)
+	sub := new(TDXCompanyInfoCategoryMessage)
 	sub.TDXCompanyInfoCategoryRequest = req
 	sub.TDXReqHeader = TDXReqHeader{0x0c, SeqID(), 0,
 		0xe, 0xe, KMSG_COMPANYCATEGORY}
 	return sub
 }
 
-func (c* TDXCompanyInfoCategoryMessage) MessageNumber() int32 {
+func (c *TDXCompanyInfoCategoryMessage) MessageNumber() int32 {
 	return KMSG_COMPANYCATEGORY
 }
 
-func (c* TDXCompanyInfoCategoryMessage) Serialize() ([]byte, error) {
+func (c *TDXCompanyInfoCategoryMessage) Serialize() ([]byte, error) {
 	buf := new(bytes.Buffer)
 	err := binary.Write(buf, binary.LittleEndian, c.TDXReqHeader)
 	err = binary.Write(buf, binary.LittleEndian, c.TDXCompanyInfoCategoryRequest)
@@ -54,28 +58,31 @@ func (c* TDXCompanyInfoCategoryMessage) Serialize() ([]byte, error) {
 	return buf.Bytes(), err
 }
 
-func (c* TDXCompanyInfoCategoryMessage) UnSerialize(header interface{}, b []byte) error {
+func (c *TDXCompanyInfoCategoryMessage) UnSerialize(header interface{}, b []byte) error {
 	h := header.(TDXRespHeader)
 	c.TDXRespHeader = h
+	if len(b) < 2 {
+		return fmt.Errorf("company info category: body too short (%d bytes)", len(b))
+	}
 	binary.Read(bytes.NewBuffer(b[:2]), binary.LittleEndian, &c.TDXCompanyInfoCategoryResponse.Num)
 
 	pos := 2
+	enc := mahonia.NewDecoder("gbk")
+	c.TDXCompanyInfoCategoryResponse.List = make([]CompanyInfoCategory, 0, c.TDXCompanyInfoCategoryResponse.Num)
 	for index := uint16(0); index < c.TDXCompanyInfoCategoryResponse.Num; index++ {
-		enc := mahonia.NewDecoder("gbk")
-
+		// 名称64 文件名80 起始4 长度4
+		if len(b) < pos+152 {
+			return fmt.Errorf("company info category: entry %d of %d truncated", index, c.TDXCompanyInfoCategoryResponse.Num)
+		}
 		cc := CompanyInfoCategory{}
-		var name [64]byte
-		binary.Read(bytes.NewBuffer(b[pos:pos+64]), binary.LittleEndian, &name)
+		cc.Name = enc.ConvertString(string(getStr(b[pos : pos+64])))
 		pos += 64
-		var fileName [80]byte
-		binary.Read(bytes.NewBuffer(b[pos:pos+80]), binary.LittleEndian, &fileName)
+		cc.FileName = string(getStr(b[pos : pos+80]))
 		pos += 80
 		binary.Read(bytes.NewBuffer(b[pos:pos+4]), binary.LittleEndian, &cc.Start)
 		pos += 4
 		binary.Read(bytes.NewBuffer(b[pos:pos+4]), binary.LittleEndian, &cc.Interval)
 		pos += 4
-		cc.Name = enc.ConvertString(string(getStr(name[:])))
-		cc.FileName = string(getStr(fileName[:]))
 
 		c.TDXCompanyInfoCategoryResponse.List = append(c.TDXCompanyInfoCategoryResponse.List, cc)
 	}
@@ -101,6 +108,18 @@ type TDXCompanyInfoContentRequest struct {
 	I2       uint32
 }
 
+// NewTDXCompanyInfoContentRequest 读取目录项 category 对应的 F10 内容
+func NewTDXCompanyInfoContentRequest(market uint16, code string, category CompanyInfoCategory) TDXCompanyInfoContentRequest {
+	req := TDXCompanyInfoContentRequest{
+		Market: market,
+		Start:  category.Start,
+		Length: category.Interval,
+	}
+	copy(req.Code[:], code)
+	copy(req.FileName[:], category.FileName)
+	return req
+}
+
 type TDXCompanyInfoContentResponse struct {
 	Content string //描述
 }
@@ -113,35 +132,38 @@ type TDXCompanyInfoContentMessage struct {
 }
 
 func NewTDXCompanyInfoContentMessage(req TDXCompanyInfoContentRequest) *TDXCompanyInfoContentMessage {
-	msg := GetMessage(KMSG_COMPANYCONTENT)
-	if (msg == nil) {
-		Register(KMSG_COMPANYCONTENT, new(TDXCompanyInfoContentMessage))
-	}
-	sub := GetMessage(KMSG_COMPANYCONTENT).(*TDXCompanyInfoContentMessage)
+	sub := new(TDXCompanyInfoContentMessage)
 	sub.TDXCompanyInfoContentRequest = req
 	sub.TDXReqHeader = TDXReqHeader{0x0c, SeqID(), 0,
 		0x68, 0x68, KMSG_COMPANYCONTENT}
 	return sub
 }
 
-func (c* TDXCompanyInfoContentMessage) MessageNumber() int32 {
-	return KMSG_COMPANYCATEGORY
+func (c *TDXCompanyInfoContentMessage) MessageNumber() int32 {
+	return KMSG_COMPANYCONTENT
 }
 
-func (c* TDXCompanyInfoContentMessage) Serialize() ([]byte, error) {
+func (c *TDXCompanyInfoContentMessage) Serialize() ([]byte, error) {
 	buf := new(bytes.Buffer)
 	err := binary.Write(buf, binary.LittleEndian, c.TDXReqHeader)
 	err = binary.Write(buf, binary.LittleEndian, c.TDXCompanyInfoContentRequest)
 	return buf.Bytes(), err
 }
 
-func (c* TDXCompanyInfoContentMessage) UnSerialize(header interface{}, b []byte) error {
+func (c *TDXCompanyInfoContentMessage) UnSerialize(header interface{}, b []byte) error {
 	h := header.(TDXRespHeader)
 	c.TDXRespHeader = h
-	enc := mahonia.NewDecoder("gbk")
+	if len(b) < 12 {
+		return fmt.Errorf("company info content: body too short (%d bytes)", len(b))
+	}
+	// 市场2 代码6 未知2 长度2 内容
 	var length uint16
 	binary.Read(bytes.NewBuffer(b[10:12]), binary.LittleEndian, &length)
-	c.Content = enc.ConvertString(string(b[12 : 12+length]))
+	end := 12 + int(length)
+	if end > len(b) {
+		return fmt.Errorf("company info content: length %d exceeds body (%d bytes)", length, len(b)-12)
+	}
+	enc := mahonia.NewDecoder("gbk")
+	c.Content = enc.ConvertString(string(b[12:end]))
 	return nil
 }
-
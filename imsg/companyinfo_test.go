@@ -0,0 +1,72 @@
+package imsg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/axgle/mahonia"
+	"testing"
+)
+
+func gbk(s string) string {
+	return mahonia.NewEncoder("gbk").ConvertString(s)
+}
+
+func TestTDXCompanyInfoCategoryMessage_UnSerialize(t *testing.T) {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, uint16(2))
+	for i, name := range []string{"最新提示", "公司概况"} {
+		var entry [152]byte
+		copy(entry[:64], gbk(name))
+		copy(entry[64:144], "600000.txt")
+		binary.LittleEndian.PutUint32(entry[144:], uint32(i*1000))
+		binary.LittleEndian.PutUint32(entry[148:], 1000)
+		buf.Write(entry[:])
+	}
+
+	msg := NewTDXCompanyInfoCategoryMessage(NewTDXCompanyInfoCategoryRequest(MARKET_SH, "600000"))
+	if err := msg.UnSerialize(TDXRespHeader{}, buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	want := []CompanyInfoCategory{
+		{Name: "最新提示", FileName: "600000.txt", Start: 0, Interval: 1000},
+		{Name: "公司概况", FileName: "600000.txt", Start: 1000, Interval: 1000},
+	}
+	if len(msg.List) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(msg.List), len(want))
+	}
+	for i := range want {
+		if msg.List[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, msg.List[i], want[i])
+		}
+	}
+
+	if err := msg.UnSerialize(TDXRespHeader{}, buf.Bytes()[:100]); err == nil {
+		t.Error("expected error for truncated entry")
+	}
+}
+
+func TestTDXCompanyInfoContentMessage_UnSerialize(t *testing.T) {
+	text := gbk("浦发银行 主营业务: 吸收公众存款")
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, uint16(MARKET_SH))
+	buf.WriteString("600000")
+	binary.Write(buf, binary.LittleEndian, uint16(0))
+	binary.Write(buf, binary.LittleEndian, uint16(len(text)))
+	buf.WriteString(text)
+
+	req := NewTDXCompanyInfoContentRequest(MARKET_SH, "600000", CompanyInfoCategory{FileName: "600000.txt", Start: 10, Interval: 20})
+	if string(getStr(req.FileName[:])) != "600000.txt" || req.Start != 10 || req.Length != 20 {
+		t.Errorf("request = %+v", req)
+	}
+	msg := NewTDXCompanyInfoContentMessage(req)
+	if err := msg.UnSerialize(TDXRespHeader{}, buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Content != "浦发银行 主营业务: 吸收公众存款" {
+		t.Errorf("Content = %q", msg.Content)
+	}
+
+	if err := msg.UnSerialize(TDXRespHeader{}, buf.Bytes()[:20]); err == nil {
+		t.Error("expected error when length exceeds body")
+	}
+}
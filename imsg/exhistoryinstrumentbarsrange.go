@@ -1 +1,81 @@
 package imsg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// TDXExHistoryInstrumentBarsRangeRequest 查询 Start 到 End 之间的全部K线, 日期格式为 yyyymmdd
+type TDXExHistoryInstrumentBarsRangeRequest struct {
+	Market uint8
+	Code   [9]byte
+	Start  uint32
+	End    uint32
+	I      uint16 // 未知
+}
+
+func NewTDXExHistoryInstrumentBarsRangeRequest(market uint8, code string, start uint32, end uint32) TDXExHistoryInstrumentBarsRangeRequest {
+	req := TDXExHistoryInstrumentBarsRangeRequest{Market: market, Start: start, End: end}
+	copy(req.Code[:], code)
+	return req
+}
+
+type TDXExHistoryInstrumentBarsRangeResponse struct {
+	Num  uint16
+	List []ExInstrumentBarsElement
+}
+
+type TDXExHistoryInstrumentBarsRangeMessage struct {
+	TDXReqHeader
+	TDXExHistoryInstrumentBarsRangeRequest
+	TDXRespHeader
+	TDXExHistoryInstrumentBarsRangeResponse
+}
+
+func NewTDXExHistoryInstrumentBarsRangeMessage(req TDXExHistoryInstrumentBarsRangeRequest) *TDXExHistoryInstrumentBarsRangeMessage {
+	sub := new(TDXExHistoryInstrumentBarsRangeMessage)
+	sub.TDXExHistoryInstrumentBarsRangeRequest = req
+	sub.TDXReqHeader = TDXReqHeader{0x01, SeqID(), 0x01,
+		0x16, 0x16, KMSG_EXHISTORYINSTRUMENTBARSRANGE}
+	return sub
+}
+
+func (c *TDXExHistoryInstrumentBarsRangeMessage) MessageNumber() int32 {
+	return KMSG_EXHISTORYINSTRUMENTBARSRANGE
+}
+
+func (c *TDXExHistoryInstrumentBarsRangeMessage) Serialize() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	err := binary.Write(buf, binary.LittleEndian, c.TDXReqHeader)
+	err = binary.Write(buf, binary.LittleEndian, c.TDXExHistoryInstrumentBarsRangeRequest)
+	return buf.Bytes(), err
+}
+
+func (c *TDXExHistoryInstrumentBarsRangeMessage) UnSerialize(header interface{}, b []byte) error {
+	h := header.(TDXRespHeader)
+	c.TDXRespHeader = h
+	// 前 12 字节未知
+	pos := 12
+	if len(b) < pos+2 {
+		return fmt.Errorf("ex history bars range: body too short (%d bytes)", len(b))
+	}
+	binary.Read(bytes.NewBuffer(b[pos:pos+2]), binary.LittleEndian, &c.Num)
+	pos += 2
+
+	c.List = make([]ExInstrumentBarsElement, 0, c.Num)
+	for index := uint16(0); index < c.Num; index++ {
+		if len(b) < pos+4+28 {
+			return fmt.Errorf("ex history bars range: bar %d of %d truncated", index, c.Num)
+		}
+		// 每根K线都带日期和分钟
+		ele := ExInstrumentBarsElement{}
+		ele.Year, ele.Month, ele.Day, ele.Hour, ele.Minute = getdatetime(KLINE_TYPE_1MIN, b, &pos)
+		var bar exBarBlock
+		binary.Read(bytes.NewBuffer(b[pos:pos+28]), binary.LittleEndian, &bar)
+		pos += 28
+		ele.fill(bar)
+		c.List = append(c.List, ele)
+	}
+	return nil
+}
@@ -1 +1,59 @@
 package imsg
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+type TDXExHistoryTransactionDataRequest struct {
+	Date   uint32 // yyyymmdd
+	Market uint8
+	Code   [9]byte
+	Start  int32
+	Count  uint16
+}
+
+func NewTDXExHistoryTransactionDataRequest(date uint32, market uint8, code string, start int32, count uint16) TDXExHistoryTransactionDataRequest {
+	req := TDXExHistoryTransactionDataRequest{Date: date, Market: market, Start: start, Count: count}
+	copy(req.Code[:], code)
+	return req
+}
+
+type TDXExHistoryTransactionDataResponse struct {
+	Num  uint16
+	List []ExTransactionElement
+}
+
+type TDXExHistoryTransactionDataMessage struct {
+	TDXReqHeader
+	TDXExHistoryTransactionDataRequest
+	TDXRespHeader
+	TDXExHistoryTransactionDataResponse
+}
+
+func NewTDXExHistoryTransactionDataMessage(req TDXExHistoryTransactionDataRequest) *TDXExHistoryTransactionDataMessage {
+	sub := new(TDXExHistoryTransactionDataMessage)
+	sub.TDXExHistoryTransactionDataRequest = req
+	sub.TDXReqHeader = TDXReqHeader{0x01, SeqID(), 0x01,
+		0x16, 0x16, KMSG_EXHISTORYTRANSACTIONDATA}
+	return sub
+}
+
+func (c *TDXExHistoryTransactionDataMessage) MessageNumber() int32 {
+	return KMSG_EXHISTORYTRANSACTIONDATA
+}
+
+func (c *TDXExHistoryTransactionDataMessage) Serialize() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	err := binary.Write(buf, binary.LittleEndian, c.TDXReqHeader)
+	err = binary.Write(buf, binary.LittleEndian, c.TDXExHistoryTransactionDataRequest)
+	return buf.Bytes(), err
+}
+
+func (c *TDXExHistoryTransactionDataMessage) UnSerialize(header interface{}, b []byte) error {
+	h := header.(TDXRespHeader)
+	c.TDXRespHeader = h
+	num, list, err := unpackExTransactions(b)
+	c.Num, c.List = num, list
+	return err
+}
@@ -1 +1,133 @@
 package imsg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+type TDXExInstrumentBarsRequest struct {
+	Market   uint8
+	Code     [9]byte
+	Category uint16 // K线种类 KLINE_TYPE_*
+	I        uint16 // 未知
+	Start    uint32
+	Count    uint16
+}
+
+func NewTDXExInstrumentBarsRequest(market uint8, code string, category uint16, start uint32, count uint16) TDXExInstrumentBarsRequest {
+	req := TDXExInstrumentBarsRequest{
+		Market:   market,
+		Category: category,
+		I:        1,
+		Start:    start,
+		Count:    count,
+	}
+	copy(req.Code[:], code)
+	return req
+}
+
+type ExInstrumentBarsElement struct {
+	Open     float64
+	High     float64
+	Low      float64
+	Close    float64
+	Position uint32  // 持仓量
+	Trade    uint32  // 成交量
+	Price    float64 // 结算价
+	Amount   float64 // 成交额, 与持仓量共用同一字段
+	Year     int
+	Month    int
+	Day      int
+	Hour     int
+	Minute   int
+	DateTime string
+}
+
+// Time K线时间, 北京时间
+func (e ExInstrumentBarsElement) Time() time.Time {
+	return time.Date(e.Year, time.Month(e.Month), e.Day, e.Hour, e.Minute, 0, 0, ChinaLocation)
+}
+
+// exBarBlock K线数据, 共 28 字节
+type exBarBlock struct {
+	Open     float32
+	High     float32
+	Low      float32
+	Close    float32
+	Position uint32
+	Trade    uint32
+	Price    float32
+}
+
+func (e *ExInstrumentBarsElement) fill(bar exBarBlock) {
+	e.Open = float64(bar.Open)
+	e.High = float64(bar.High)
+	e.Low = float64(bar.Low)
+	e.Close = float64(bar.Close)
+	e.Position = bar.Position
+	e.Trade = bar.Trade
+	e.Price = float64(bar.Price)
+	e.Amount = float64(math.Float32frombits(bar.Position))
+	e.DateTime = fmt.Sprintf("%d-%02d-%02d %02d:%02d:00", e.Year, e.Month, e.Day, e.Hour, e.Minute)
+}
+
+type TDXExInstrumentBarsResponse struct {
+	Num  uint16
+	List []ExInstrumentBarsElement
+}
+
+type TDXExInstrumentBarsMessage struct {
+	TDXReqHeader
+	TDXExInstrumentBarsRequest
+	TDXRespHeader
+	TDXExInstrumentBarsResponse
+}
+
+func NewTDXExInstrumentBarsMessage(req TDXExInstrumentBarsRequest) *TDXExInstrumentBarsMessage {
+	sub := new(TDXExInstrumentBarsMessage)
+	sub.TDXExInstrumentBarsRequest = req
+	sub.TDXReqHeader = TDXReqHeader{0x01, SeqID(), 0x01,
+		0x16, 0x16, KMSG_EXINSTRUMENTBARS}
+	return sub
+}
+
+func (c *TDXExInstrumentBarsMessage) MessageNumber() int32 {
+	return KMSG_EXINSTRUMENTBARS
+}
+
+func (c *TDXExInstrumentBarsMessage) Serialize() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	err := binary.Write(buf, binary.LittleEndian, c.TDXReqHeader)
+	err = binary.Write(buf, binary.LittleEndian, c.TDXExInstrumentBarsRequest)
+	return buf.Bytes(), err
+}
+
+func (c *TDXExInstrumentBarsMessage) UnSerialize(header interface{}, b []byte) error {
+	h := header.(TDXRespHeader)
+	c.TDXRespHeader = h
+	// 前 18 字节为请求回显
+	pos := 18
+	if len(b) < pos+2 {
+		return fmt.Errorf("ex instrument bars: body too short (%d bytes)", len(b))
+	}
+	binary.Read(bytes.NewBuffer(b[pos:pos+2]), binary.LittleEndian, &c.Num)
+	pos += 2
+
+	c.List = make([]ExInstrumentBarsElement, 0, c.Num)
+	for index := uint16(0); index < c.Num; index++ {
+		if len(b) < pos+4+28 {
+			return fmt.Errorf("ex instrument bars: bar %d of %d truncated", index, c.Num)
+		}
+		ele := ExInstrumentBarsElement{}
+		ele.Year, ele.Month, ele.Day, ele.Hour, ele.Minute = getdatetime(int(c.Category), b, &pos)
+		var bar exBarBlock
+		binary.Read(bytes.NewBuffer(b[pos:pos+28]), binary.LittleEndian, &bar)
+		pos += 28
+		ele.fill(bar)
+		c.List = append(c.List, ele)
+	}
+	return nil
+}
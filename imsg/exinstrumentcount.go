@@ -1 +1,45 @@
 package imsg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+type TDXExInstrumentCountResponse struct {
+	Count uint32
+}
+
+type TDXExInstrumentCountMessage struct {
+	TDXReqHeader
+	TDXRespHeader
+	TDXExInstrumentCountResponse
+}
+
+func NewTDXExInstrumentCountMessage() *TDXExInstrumentCountMessage {
+	sub := new(TDXExInstrumentCountMessage)
+	sub.TDXReqHeader = TDXReqHeader{0x01, SeqID(), 0x01,
+		0x02, 0x02, KMSG_EXINSTRUMENTCOUNT}
+	return sub
+}
+
+func (c *TDXExInstrumentCountMessage) MessageNumber() int32 {
+	return KMSG_EXINSTRUMENTCOUNT
+}
+
+func (c *TDXExInstrumentCountMessage) Serialize() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	err := binary.Write(buf, binary.LittleEndian, c.TDXReqHeader)
+	return buf.Bytes(), err
+}
+
+func (c *TDXExInstrumentCountMessage) UnSerialize(header interface{}, b []byte) error {
+	h := header.(TDXRespHeader)
+	c.TDXRespHeader = h
+	// 前 19 字节未知
+	if len(b) < 23 {
+		return fmt.Errorf("ex instrument count: body too short (%d bytes)", len(b))
+	}
+	binary.Read(bytes.NewBuffer(b[19:23]), binary.LittleEndian, &c.Count)
+	return nil
+}
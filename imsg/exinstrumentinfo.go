@@ -1 +1,80 @@
 package imsg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+type TDXExInstrumentInfoRequest struct {
+	Start uint32
+	Count uint16
+}
+
+type ExInstrumentInfoElement struct {
+	Category uint8
+	Market   uint8
+	Code     string
+	Name     string
+	Desc     string
+}
+
+type TDXExInstrumentInfoResponse struct {
+	Start uint32
+	Count uint16
+	List  []ExInstrumentInfoElement
+}
+
+type TDXExInstrumentInfoMessage struct {
+	TDXReqHeader
+	TDXExInstrumentInfoRequest
+	TDXRespHeader
+	TDXExInstrumentInfoResponse
+}
+
+func NewTDXExInstrumentInfoMessage(req TDXExInstrumentInfoRequest) *TDXExInstrumentInfoMessage {
+	sub := new(TDXExInstrumentInfoMessage)
+	sub.TDXExInstrumentInfoRequest = req
+	sub.TDXReqHeader = TDXReqHeader{0x01, SeqID(), 0x01,
+		0x08, 0x08, KMSG_EXINSTRUMENTINFO}
+	return sub
+}
+
+func (c *TDXExInstrumentInfoMessage) MessageNumber() int32 {
+	return KMSG_EXINSTRUMENTINFO
+}
+
+func (c *TDXExInstrumentInfoMessage) Serialize() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	err := binary.Write(buf, binary.LittleEndian, c.TDXReqHeader)
+	err = binary.Write(buf, binary.LittleEndian, c.TDXExInstrumentInfoRequest)
+	return buf.Bytes(), err
+}
+
+func (c *TDXExInstrumentInfoMessage) UnSerialize(header interface{}, b []byte) error {
+	h := header.(TDXRespHeader)
+	c.TDXRespHeader = h
+	if len(b) < 6 {
+		return fmt.Errorf("ex instrument info: body too short (%d bytes)", len(b))
+	}
+	binary.Read(bytes.NewBuffer(b[0:4]), binary.LittleEndian, &c.TDXExInstrumentInfoResponse.Start)
+	binary.Read(bytes.NewBuffer(b[4:6]), binary.LittleEndian, &c.TDXExInstrumentInfoResponse.Count)
+
+	// 每条 64 字节: 类别1 市场1 未知3 代码9 名称17 描述9 未知24
+	pos := 6
+	c.List = make([]ExInstrumentInfoElement, 0, c.TDXExInstrumentInfoResponse.Count)
+	for index := uint16(0); index < c.TDXExInstrumentInfoResponse.Count; index++ {
+		if len(b) < pos+40 {
+			return fmt.Errorf("ex instrument info: entry %d of %d truncated", index, c.TDXExInstrumentInfoResponse.Count)
+		}
+		c.List = append(c.List, ExInstrumentInfoElement{
+			Category: b[pos],
+			Market:   b[pos+1],
+			Code:     getgbkstr(b[pos+5 : pos+14]),
+			Name:     getgbkstr(b[pos+14 : pos+31]),
+			Desc:     getgbkstr(b[pos+31 : pos+40]),
+		})
+		pos += 64
+	}
+	return nil
+}
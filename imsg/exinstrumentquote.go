@@ -1 +1,126 @@
 package imsg
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+type TDXExInstrumentQuoteRequest struct {
+	Market uint8
+	Code   [9]byte
+}
+
+func NewTDXExInstrumentQuoteRequest(market uint8, code string) TDXExInstrumentQuoteRequest {
+	req := TDXExInstrumentQuoteRequest{Market: market}
+	copy(req.Code[:], code)
+	return req
+}
+
+type ExInstrumentQuoteElement struct {
+	Market      uint8
+	Code        string
+	PreClose    float64 // 昨收, 期货为昨结算价(客户端显示为"昨结")
+	Open        float64
+	High        float64
+	Low         float64
+	Price       float64
+	KaiCang     int     // 开仓
+	ZongLiang   int     // 总量
+	XianLiang   int     // 现量
+	ZongJinE    float64 // 总金额
+	NeiPan      int     // 内盘
+	WaiPan      int     // 外盘
+	ChiCang     int     // 持仓
+	BidLevels   []Level
+	OfferLevels []Level
+}
+
+// exQuoteBlock 行情数据, 共 136 字节, 字段名与 pytdx 行情列表的解析一致, 未命名的字段含义未知
+type exQuoteBlock struct {
+	PreClose  float32 // ZuoJie
+	Open      float32
+	High      float32
+	Low       float32
+	Price     float32
+	KaiCang   uint32
+	_         uint32
+	ZongLiang uint32
+	XianLiang uint32
+	ZongJinE  float32
+	NeiPan    uint32
+	WaiPan    uint32
+	_         uint32
+	ChiCang   uint32
+	BidPrice  [5]float32
+	BidVol    [5]uint32
+	AskPrice  [5]float32
+	AskVol    [5]uint32
+}
+
+// unpack 从 b 中解析 市场1 代码9 未知4 行情136, 返回读取的字节数
+func (e *ExInstrumentQuoteElement) unpack(b []byte) int {
+	e.Market = b[0]
+	e.Code = getgbkstr(b[1:10])
+	var q exQuoteBlock
+	binary.Read(bytes.NewBuffer(b[14:150]), binary.LittleEndian, &q)
+	e.PreClose = float64(q.PreClose)
+	e.Open = float64(q.Open)
+	e.High = float64(q.High)
+	e.Low = float64(q.Low)
+	e.Price = float64(q.Price)
+	e.KaiCang = int(q.KaiCang)
+	e.ZongLiang = int(q.ZongLiang)
+	e.XianLiang = int(q.XianLiang)
+	e.ZongJinE = float64(q.ZongJinE)
+	e.NeiPan = int(q.NeiPan)
+	e.WaiPan = int(q.WaiPan)
+	e.ChiCang = int(q.ChiCang)
+	e.BidLevels = make([]Level, 5)
+	e.OfferLevels = make([]Level, 5)
+	for i := 0; i < 5; i++ {
+		e.BidLevels[i] = Level{Price: float64(q.BidPrice[i]), Vol: int(q.BidVol[i])}
+		e.OfferLevels[i] = Level{Price: float64(q.AskPrice[i]), Vol: int(q.AskVol[i])}
+	}
+	return 150
+}
+
+type TDXExInstrumentQuoteResponse struct {
+	ExInstrumentQuoteElement
+}
+
+type TDXExInstrumentQuoteMessage struct {
+	TDXReqHeader
+	TDXExInstrumentQuoteRequest
+	TDXRespHeader
+	TDXExInstrumentQuoteResponse
+}
+
+func NewTDXExInstrumentQuoteMessage(req TDXExInstrumentQuoteRequest) *TDXExInstrumentQuoteMessage {
+	sub := new(TDXExInstrumentQuoteMessage)
+	sub.TDXExInstrumentQuoteRequest = req
+	sub.TDXReqHeader = TDXReqHeader{0x01, SeqID(), 0x01,
+		0x0c, 0x0c, KMSG_EXINSTRUMENTQUOTE}
+	return sub
+}
+
+func (c *TDXExInstrumentQuoteMessage) MessageNumber() int32 {
+	return KMSG_EXINSTRUMENTQUOTE
+}
+
+func (c *TDXExInstrumentQuoteMessage) Serialize() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	err := binary.Write(buf, binary.LittleEndian, c.TDXReqHeader)
+	err = binary.Write(buf, binary.LittleEndian, c.TDXExInstrumentQuoteRequest)
+	return buf.Bytes(), err
+}
+
+// UnSerialize 品种不存在时服务器返回的数据不足一条行情, 此时结果为空
+func (c *TDXExInstrumentQuoteMessage) UnSerialize(header interface{}, b []byte) error {
+	h := header.(TDXRespHeader)
+	c.TDXRespHeader = h
+	if len(b) < 150 {
+		return nil
+	}
+	c.unpack(b)
+	return nil
+}
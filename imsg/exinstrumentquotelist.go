@@ -1 +1,103 @@
-package imsg
\ No newline at end of file
+package imsg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	// 拓展市场类别, 见 ExMarketElement.Category
+	EX_CATEGORY_HK      = 2 // 港股
+	EX_CATEGORY_FUTURES = 3 // 期货
+
+	EX_QUOTE_LIST_PAGE = 80 // 行情列表每页数量
+)
+
+// exQuoteListStride 各类别行情列表中每条记录的长度
+var exQuoteListStride = map[uint8]int{
+	EX_CATEGORY_HK:      290,
+	EX_CATEGORY_FUTURES: 314,
+}
+
+// TDXExInstrumentQuoteListRequest 按市场分页查询行情, Category 只用于解析响应, 不发送给服务器
+type TDXExInstrumentQuoteListRequest struct {
+	Market   uint8
+	Category uint8
+	Start    uint16
+	Count    uint16
+}
+
+func NewTDXExInstrumentQuoteListRequest(market uint8, category uint8, start uint16, count uint16) TDXExInstrumentQuoteListRequest {
+	return TDXExInstrumentQuoteListRequest{Market: market, Category: category, Start: start, Count: count}
+}
+
+type ExInstrumentQuoteListElement struct {
+	ExInstrumentQuoteElement
+	// Extra 记录中五档行情之后的部分, 港股 140 字节, 期货 164 字节, 布局尚未确认, 保留原始数据
+	Extra []byte
+}
+
+type TDXExInstrumentQuoteListResponse struct {
+	Num  uint16
+	List []ExInstrumentQuoteListElement
+}
+
+type TDXExInstrumentQuoteListMessage struct {
+	TDXReqHeader
+	TDXExInstrumentQuoteListRequest
+	TDXRespHeader
+	TDXExInstrumentQuoteListResponse
+}
+
+func NewTDXExInstrumentQuoteListMessage(req TDXExInstrumentQuoteListRequest) *TDXExInstrumentQuoteListMessage {
+	sub := new(TDXExInstrumentQuoteListMessage)
+	sub.TDXExInstrumentQuoteListRequest = req
+	sub.TDXReqHeader = TDXReqHeader{0x01, SeqID(), 0x02,
+		0x0b, 0x0b, KMSG_EXINSTRUMENTQUOTELIST}
+	return sub
+}
+
+func (c *TDXExInstrumentQuoteListMessage) MessageNumber() int32 {
+	return KMSG_EXINSTRUMENTQUOTELIST
+}
+
+func (c *TDXExInstrumentQuoteListMessage) Serialize() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	err := binary.Write(buf, binary.LittleEndian, c.TDXReqHeader)
+	err = binary.Write(buf, binary.LittleEndian, struct {
+		Market uint8
+		I1     uint16
+		Start  uint16
+		Count  uint16
+		I2     uint16
+	}{c.Market, 0, c.Start, c.Count, 1})
+	return buf.Bytes(), err
+}
+
+func (c *TDXExInstrumentQuoteListMessage) UnSerialize(header interface{}, b []byte) error {
+	h := header.(TDXRespHeader)
+	c.TDXRespHeader = h
+	stride, ok := exQuoteListStride[c.Category]
+	if !ok {
+		return fmt.Errorf("ex quote list: unsupported category %d", c.Category)
+	}
+	if len(b) < 2 {
+		return fmt.Errorf("ex quote list: body too short (%d bytes)", len(b))
+	}
+	binary.Read(bytes.NewBuffer(b[:2]), binary.LittleEndian, &c.Num)
+
+	pos := 2
+	c.List = make([]ExInstrumentQuoteListElement, 0, c.Num)
+	for index := uint16(0); index < c.Num; index++ {
+		if len(b) < pos+stride {
+			return fmt.Errorf("ex quote list: record %d of %d truncated", index, c.Num)
+		}
+		ele := ExInstrumentQuoteListElement{}
+		n := ele.unpack(b[pos : pos+stride])
+		ele.Extra = append([]byte(nil), b[pos+n:pos+stride]...)
+		pos += stride
+		c.List = append(c.List, ele)
+	}
+	return nil
+}
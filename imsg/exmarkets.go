@@ -1 +1,75 @@
 package imsg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+type ExMarketElement struct {
+	Market    uint8
+	Category  uint8
+	Name      string
+	ShortName string
+}
+
+type TDXExMarketsResponse struct {
+	Num  uint16
+	List []ExMarketElement
+}
+
+type TDXExMarketsMessage struct {
+	TDXReqHeader
+	TDXRespHeader
+	TDXExMarketsResponse
+}
+
+func NewTDXExMarketsMessage() *TDXExMarketsMessage {
+	sub := new(TDXExMarketsMessage)
+	sub.TDXReqHeader = TDXReqHeader{0x01, SeqID(), 0x01,
+		0x02, 0x02, KMSG_EXMARKETS}
+	return sub
+}
+
+func (c *TDXExMarketsMessage) MessageNumber() int32 {
+	return KMSG_EXMARKETS
+}
+
+func (c *TDXExMarketsMessage) Serialize() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	err := binary.Write(buf, binary.LittleEndian, c.TDXReqHeader)
+	return buf.Bytes(), err
+}
+
+func (c *TDXExMarketsMessage) UnSerialize(header interface{}, b []byte) error {
+	h := header.(TDXRespHeader)
+	c.TDXRespHeader = h
+	if len(b) < 2 {
+		return fmt.Errorf("ex markets: body too short (%d bytes)", len(b))
+	}
+	var num uint16
+	binary.Read(bytes.NewBuffer(b[:2]), binary.LittleEndian, &num)
+
+	// 每个市场 62 字节: 类别1 名称32 市场1 简称2 未知26
+	pos := 2
+	c.List = make([]ExMarketElement, 0, num)
+	for index := uint16(0); index < num; index++ {
+		if len(b) < pos+62 {
+			return fmt.Errorf("ex markets: entry %d of %d truncated", index, num)
+		}
+		ele := ExMarketElement{
+			Category:  b[pos],
+			Name:      getgbkstr(b[pos+1 : pos+33]),
+			Market:    b[pos+33],
+			ShortName: getgbkstr(b[pos+34 : pos+36]),
+		}
+		pos += 62
+		// 空白记录
+		if ele.Category == 0 && ele.Market == 0 {
+			continue
+		}
+		c.List = append(c.List, ele)
+	}
+	c.Num = uint16(len(c.List))
+	return nil
+}
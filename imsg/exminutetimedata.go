@@ -1 +1,104 @@
 package imsg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+type TDXExMinuteTimeDataRequest struct {
+	Market uint8
+	Code   [9]byte
+}
+
+func NewTDXExMinuteTimeDataRequest(market uint8, code string) TDXExMinuteTimeDataRequest {
+	req := TDXExMinuteTimeDataRequest{Market: market}
+	copy(req.Code[:], code)
+	return req
+}
+
+type ExMinuteTimeElement struct {
+	Hour         int
+	Minute       int
+	Price        float64
+	AvgPrice     float64 // 均价
+	Volume       uint32
+	OpenInterest uint32 // 持仓量
+}
+
+// exMinuteBlock 分时数据, 共 18 字节
+type exMinuteBlock struct {
+	Time     uint16 // 从 0 点开始的分钟数
+	Price    float32
+	AvgPrice float32
+	Volume   uint32
+	Amount   uint32
+}
+
+type TDXExMinuteTimeDataResponse struct {
+	Num  uint16
+	List []ExMinuteTimeElement
+}
+
+type TDXExMinuteTimeDataMessage struct {
+	TDXReqHeader
+	TDXExMinuteTimeDataRequest
+	TDXRespHeader
+	TDXExMinuteTimeDataResponse
+}
+
+func NewTDXExMinuteTimeDataMessage(req TDXExMinuteTimeDataRequest) *TDXExMinuteTimeDataMessage {
+	sub := new(TDXExMinuteTimeDataMessage)
+	sub.TDXExMinuteTimeDataRequest = req
+	sub.TDXReqHeader = TDXReqHeader{0x01, SeqID(), 0x01,
+		0x0c, 0x0c, KMSG_EXMINUTETIMEDATA}
+	return sub
+}
+
+func (c *TDXExMinuteTimeDataMessage) MessageNumber() int32 {
+	return KMSG_EXMINUTETIMEDATA
+}
+
+func (c *TDXExMinuteTimeDataMessage) Serialize() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	err := binary.Write(buf, binary.LittleEndian, c.TDXReqHeader)
+	err = binary.Write(buf, binary.LittleEndian, c.TDXExMinuteTimeDataRequest)
+	return buf.Bytes(), err
+}
+
+func (c *TDXExMinuteTimeDataMessage) UnSerialize(header interface{}, b []byte) error {
+	h := header.(TDXRespHeader)
+	c.TDXRespHeader = h
+	num, list, err := unpackExMinutes(b, 12)
+	c.Num, c.List = num, list
+	return err
+}
+
+// unpackExMinutes 解析 head 字节之后的分时记录, 记录数量为记录之前的 2 字节:
+// 实时分时为 市场1 代码9 数量2, head 为 12; 历史分时为 市场1 代码9 未知8 数量2, head 为 20
+func unpackExMinutes(b []byte, head int) (uint16, []ExMinuteTimeElement, error) {
+	if len(b) < head {
+		return 0, nil, fmt.Errorf("ex minute data: body too short (%d bytes)", len(b))
+	}
+	var num uint16
+	binary.Read(bytes.NewBuffer(b[head-2:head]), binary.LittleEndian, &num)
+	pos := head
+	list := make([]ExMinuteTimeElement, 0, num)
+	for index := uint16(0); index < num; index++ {
+		if len(b) < pos+18 {
+			return num, list, fmt.Errorf("ex minute data: record %d of %d truncated", index, num)
+		}
+		var m exMinuteBlock
+		binary.Read(bytes.NewBuffer(b[pos:pos+18]), binary.LittleEndian, &m)
+		pos += 18
+		list = append(list, ExMinuteTimeElement{
+			Hour:         int(m.Time / 60),
+			Minute:       int(m.Time % 60),
+			Price:        float64(m.Price),
+			AvgPrice:     float64(m.AvgPrice),
+			Volume:       m.Volume,
+			OpenInterest: m.Amount,
+		})
+	}
+	return num, list, nil
+}
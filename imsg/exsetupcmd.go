@@ -1 +1,33 @@
-package imsg
\ No newline at end of file
+package imsg
+
+import (
+	"encoding/hex"
+	"strings"
+)
+
+// NewExSetupMessage 创建拓展行情登录消息
+func NewExSetupMessage() *ExSetupMessage {
+	sub := new(ExSetupMessage)
+	sub.Content = "010148650001520052005424" +
+		strings.Repeat("1f32c6e5d53dfb41", 8) +
+		"cce16dffd5ba3fb8cbc57a054f7748ea"
+	return sub
+}
+
+// ExSetupMessage 拓展行情登录命令
+type ExSetupMessage struct {
+	Content string
+}
+
+func (c *ExSetupMessage) MessageNumber() int32 {
+	return KMSG_EXSETUP
+}
+
+func (c *ExSetupMessage) Serialize() ([]byte, error) {
+	return hex.DecodeString(c.Content)
+}
+
+func (c *ExSetupMessage) UnSerialize(header interface{}, b []byte) error {
+	c.Content = string(b)
+	return nil
+}
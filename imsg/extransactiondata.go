@@ -1 +1,179 @@
 package imsg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+type TDXExTransactionDataRequest struct {
+	Market uint8
+	Code   [9]byte
+	Start  int32
+	Count  uint16
+}
+
+func NewTDXExTransactionDataRequest(market uint8, code string, start int32, count uint16) TDXExTransactionDataRequest {
+	req := TDXExTransactionDataRequest{Market: market, Start: start, Count: count}
+	copy(req.Code[:], code)
+	return req
+}
+
+// TradeNature 期货成交性质
+type TradeNature int
+
+const (
+	NatureUnknown    TradeNature = iota
+	NatureOpenLong               // 多开
+	NatureOpenShort              // 空开
+	NatureDualOpen               // 双开
+	NatureCloseLong              // 多平
+	NatureCloseShort             // 空平
+	NatureDualClose              // 双平
+	NatureLongSwap               // 多换
+	NatureShortSwap              // 空换
+)
+
+var natureNames = map[TradeNature]string{
+	NatureUnknown:    "未知",
+	NatureOpenLong:   "多开",
+	NatureOpenShort:  "空开",
+	NatureDualOpen:   "双开",
+	NatureCloseLong:  "多平",
+	NatureCloseShort: "空平",
+	NatureDualClose:  "双平",
+	NatureLongSwap:   "多换",
+	NatureShortSwap:  "空换",
+}
+
+func (n TradeNature) String() string {
+	if name, ok := natureNames[n]; ok {
+		return name
+	}
+	return natureNames[NatureUnknown]
+}
+
+type ExTransactionElement struct {
+	Hour      int
+	Minute    int
+	Second    int
+	Price     int         // 成交价, 服务器返回的原始整数
+	Volume    int         // 成交量
+	ZengCang  int         // 增仓
+	Direction int         // 1 主动买, -1 主动卖, 0 未知
+	Nature    TradeNature // 成交性质
+	NatureRaw uint16      // 原始性质字段, 万位为买卖方向, 其余为秒
+}
+
+// decodeNature 由买卖方向和增仓推算成交性质
+func (e *ExTransactionElement) decodeNature() {
+	e.Second = int(e.NatureRaw % 10000)
+	if e.Second > 59 {
+		e.Second = 0
+	}
+	switch e.NatureRaw / 10000 {
+	case 0:
+		e.Direction = 1
+		e.Nature = nature(e.Volume, e.ZengCang, NatureOpenLong, NatureLongSwap, NatureCloseShort)
+	case 1:
+		e.Direction = -1
+		e.Nature = nature(e.Volume, e.ZengCang, NatureOpenShort, NatureShortSwap, NatureCloseLong)
+	default:
+		e.Direction = 0
+		e.Nature = NatureUnknown
+	}
+}
+
+// nature 增仓时为 open, 持仓不变为 swap, 减仓时为 close; 成交量全部为开仓或平仓时为双开或双平
+func nature(volume, zengcang int, open, swap, close TradeNature) TradeNature {
+	switch {
+	case zengcang > 0 && volume == zengcang:
+		return NatureDualOpen
+	case zengcang > 0:
+		return open
+	case zengcang == 0:
+		return swap
+	case volume == -zengcang:
+		return NatureDualClose
+	default:
+		return close
+	}
+}
+
+// exTransactionBlock 分笔数据, 共 16 字节
+type exTransactionBlock struct {
+	Time     uint16
+	Price    uint32
+	Volume   uint32
+	ZengCang int32
+	Nature   uint16
+}
+
+type TDXExTransactionDataResponse struct {
+	Num  uint16
+	List []ExTransactionElement
+}
+
+type TDXExTransactionDataMessage struct {
+	TDXReqHeader
+	TDXExTransactionDataRequest
+	TDXRespHeader
+	TDXExTransactionDataResponse
+}
+
+func NewTDXExTransactionDataMessage(req TDXExTransactionDataRequest) *TDXExTransactionDataMessage {
+	sub := new(TDXExTransactionDataMessage)
+	sub.TDXExTransactionDataRequest = req
+	sub.TDXReqHeader = TDXReqHeader{0x01, SeqID(), 0x01,
+		0x12, 0x12, KMSG_EXTRANSACTIONDATA}
+	return sub
+}
+
+func (c *TDXExTransactionDataMessage) MessageNumber() int32 {
+	return KMSG_EXTRANSACTIONDATA
+}
+
+func (c *TDXExTransactionDataMessage) Serialize() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	err := binary.Write(buf, binary.LittleEndian, c.TDXReqHeader)
+	err = binary.Write(buf, binary.LittleEndian, c.TDXExTransactionDataRequest)
+	return buf.Bytes(), err
+}
+
+func (c *TDXExTransactionDataMessage) UnSerialize(header interface{}, b []byte) error {
+	h := header.(TDXRespHeader)
+	c.TDXRespHeader = h
+	num, list, err := unpackExTransactions(b)
+	c.Num, c.List = num, list
+	return err
+}
+
+// unpackExTransactions 解析 市场1 代码9 未知4 数量2 之后的分笔记录
+func unpackExTransactions(b []byte) (uint16, []ExTransactionElement, error) {
+	if len(b) < 16 {
+		return 0, nil, fmt.Errorf("ex transaction data: body too short (%d bytes)", len(b))
+	}
+	var num uint16
+	binary.Read(bytes.NewBuffer(b[14:16]), binary.LittleEndian, &num)
+	pos := 16
+	list := make([]ExTransactionElement, 0, num)
+	for index := uint16(0); index < num; index++ {
+		if len(b) < pos+16 {
+			return num, list, fmt.Errorf("ex transaction data: record %d of %d truncated", index, num)
+		}
+		var t exTransactionBlock
+		binary.Read(bytes.NewBuffer(b[pos:pos+16]), binary.LittleEndian, &t)
+		pos += 16
+		ele := ExTransactionElement{
+			Hour:      int(t.Time / 60),
+			Minute:    int(t.Time % 60),
+			Price:     int(t.Price),
+			Volume:    int(t.Volume),
+			ZengCang:  int(t.ZengCang),
+			NatureRaw: t.Nature,
+		}
+		ele.decodeNature()
+		list = append(list, ele)
+	}
+	return num, list, nil
+}
@@ -0,0 +1,37 @@
+package imsg
+
+import "testing"
+
+func TestExTransactionElement_Nature(t *testing.T) {
+	tests := []struct {
+		raw       uint16
+		volume    int
+		zengcang  int
+		direction int
+		second    int
+		nature    TradeNature
+	}{
+		{raw: 5, volume: 10, zengcang: 6, direction: 1, second: 5, nature: NatureOpenLong},
+		{raw: 10005, volume: 10, zengcang: 6, direction: -1, second: 5, nature: NatureOpenShort},
+		{raw: 0, volume: 10, zengcang: 10, direction: 1, nature: NatureDualOpen},
+		{raw: 10000, volume: 10, zengcang: 10, direction: -1, nature: NatureDualOpen},
+		{raw: 30, volume: 10, zengcang: 0, direction: 1, second: 30, nature: NatureLongSwap},
+		{raw: 10030, volume: 10, zengcang: 0, direction: -1, second: 30, nature: NatureShortSwap},
+		{raw: 59, volume: 10, zengcang: -4, direction: 1, second: 59, nature: NatureCloseShort},
+		{raw: 10059, volume: 10, zengcang: -4, direction: -1, second: 59, nature: NatureCloseLong},
+		{raw: 1, volume: 10, zengcang: -10, direction: 1, second: 1, nature: NatureDualClose},
+		{raw: 20001, volume: 10, zengcang: 3, direction: 0, second: 1, nature: NatureUnknown},
+		{raw: 99, volume: 10, zengcang: 3, direction: 1, second: 0, nature: NatureOpenLong},
+	}
+	for _, tt := range tests {
+		e := ExTransactionElement{NatureRaw: tt.raw, Volume: tt.volume, ZengCang: tt.zengcang}
+		e.decodeNature()
+		if e.Direction != tt.direction || e.Second != tt.second || e.Nature != tt.nature {
+			t.Errorf("raw %d vol %d zc %d: got direction %d second %d %v, want %d %d %v",
+				tt.raw, tt.volume, tt.zengcang, e.Direction, e.Second, e.Nature, tt.direction, tt.second, tt.nature)
+		}
+	}
+	if NatureCloseLong.String() != "多平" || TradeNature(100).String() != "未知" {
+		t.Error("unexpected nature names")
+	}
+}
@@ -4,46 +4,59 @@ import (
 	"bytes"
 	"encoding/binary"
 	"encoding/hex"
+	"fmt"
+	"time"
 )
 
+// TDXFinanceInfoResponse 财务信息, 股本单位为股, 金额单位为元
 type TDXFinanceInfoResponse struct {
 	Market      uint8
 	Code        [6]byte
-	Ltgb        float32 // 流通股本
-	Province    uint16  // 所属省份
-	Industry    uint16  // 所属行业
-	UpdatedDate uint32  // 更新日期
-	IPODate     uint32  // IPO日期
-	Zgb         float32 // 总股本
-	Gjg         float32 // 国家股
-	Fqrfrg      float32 // 发起人法人股
-	Frg         float32 // 法人股
-	Bg          float32 // B股
-	Hg          float32 // H股
-	Zgg         float32 // 职工股
-	Zzc         float32 // 总资产
-	Ldzc        float32 // 流动资产
-	Gdzc        float32 // 固定资产
-	Wxzc        float32 // 无形资产
-	Gdrs        float32 // 股东人数
-	Ldfc        float32 // 流动负债
-	Cqfc        float32 // 长期负债
-	Zbgjj       float32 // 资本公积金
-	Jzc         float32 // 净资产
-	Zysr        float32 // 主营收入
-	Zylr        float32 // 主营利润
-	Yszk        float32 // 应收账款
-	Yylr        float32 // 营业利润
-	Tzsy        float32 // 投资收益
-	Jyxjl       float32 // 经营现金流
-	Zxjl        float32 // 总现金流
-	Ch          float32 // 存货
-	Lrzh        float32 // 利润总和
-	Shlr        float32 // 税后利润
-	Jlr         float32 // 净利润
-	Wflr        float32 // 未分利润
-	Bl1         float32 // 保留1
-	Bl2         float32 // 保留2
+	Ltgb        float64   // 流通股本
+	Province    uint16    // 所属省份
+	Industry    uint16    // 所属行业
+	UpdatedDate time.Time // 更新日期
+	IPODate     time.Time // IPO日期
+	Zgb         float64   // 总股本
+	Gjg         float64   // 国家股
+	Fqrfrg      float64   // 发起人法人股
+	Frg         float64   // 法人股
+	Bg          float64   // B股
+	Hg          float64   // H股
+	Zgg         float64   // 职工股
+	Zzc         float64   // 总资产
+	Ldzc        float64   // 流动资产
+	Gdzc        float64   // 固定资产
+	Wxzc        float64   // 无形资产
+	Gdrs        float64   // 股东人数
+	Ldfc        float64   // 流动负债
+	Cqfc        float64   // 长期负债
+	Zbgjj       float64   // 资本公积金
+	Jzc         float64   // 净资产
+	Zysr        float64   // 主营收入
+	Zylr        float64   // 主营利润
+	Yszk        float64   // 应收账款
+	Yylr        float64   // 营业利润
+	Tzsy        float64   // 投资收益
+	Jyxjl       float64   // 经营现金流
+	Zxjl        float64   // 总现金流
+	Ch          float64   // 存货
+	Lrzh        float64   // 利润总和
+	Shlr        float64   // 税后利润
+	Jlr         float64   // 净利润
+	Wflr        float64   // 未分利润
+	Bl1         float64   // 保留1, 每股净资产
+	Bl2         float64   // 保留2
+}
+
+// financeInfoBlock 财务信息原始数据, 共 136 字节, 股本和金额单位为万
+type financeInfoBlock struct {
+	Ltgb        float32
+	Province    uint16
+	Industry    uint16
+	UpdatedDate uint32
+	IPODate     uint32
+	Values      [30]float32 // 从总股本到保留2, 与 TDXFinanceInfoResponse 字段顺序相同
 }
 
 type TDXFinanceInfoRequest struct {
@@ -60,11 +73,7 @@ type TDXFinanceInfoMessage struct {
 }
 
 func NewTDXFinanceInfoMessage(req TDXFinanceInfoRequest) *TDXFinanceInfoMessage {
-	msg := GetMessage(KMSG_FINANCEINFO)
-	if (msg == nil) {
-		Register(KMSG_FINANCEINFO, new(TDXFinanceInfoMessage))
-	}
-	sub := GetMessage(KMSG_FINANCEINFO).(*TDXFinanceInfoMessage)
+	sub := new(TDXFinanceInfoMessage)
 	sub.TDXFinanceInfoRequest = req
 	sub.Content = "0100"
 	sub.TDXReqHeader = TDXReqHeader{0x0c, SeqID(), 0,
@@ -72,11 +81,11 @@ func NewTDXFinanceInfoMessage(req TDXFinanceInfoRequest) *TDXFinanceInfoMessage
 	return sub
 }
 
-func (c* TDXFinanceInfoMessage) MessageNumber() int32 {
+func (c *TDXFinanceInfoMessage) MessageNumber() int32 {
 	return KMSG_FINANCEINFO
 }
 
-func (c* TDXFinanceInfoMessage) Serialize() ([]byte, error) {
+func (c *TDXFinanceInfoMessage) Serialize() ([]byte, error) {
 	buf := new(bytes.Buffer)
 	err := binary.Write(buf, binary.LittleEndian, c.TDXReqHeader)
 	b, err := hex.DecodeString(c.Content)
@@ -85,11 +94,35 @@ func (c* TDXFinanceInfoMessage) Serialize() ([]byte, error) {
 	return buf.Bytes(), err
 }
 
-func (c* TDXFinanceInfoMessage) UnSerialize(header interface{}, b []byte) error {
+func (c *TDXFinanceInfoMessage) UnSerialize(header interface{}, b []byte) error {
 	h := header.(TDXRespHeader)
 	c.TDXRespHeader = h
-	pos := 0
-	pos += 2
-	binary.Read(bytes.NewBuffer(b[pos:]), binary.LittleEndian, c.TDXFinanceInfoResponse)
+	pos := 2
+	if len(b) < pos+7+binary.Size(financeInfoBlock{}) {
+		return fmt.Errorf("finance info: body too short (%d bytes)", len(b))
+	}
+	c.TDXFinanceInfoResponse.Market = b[pos]
+	copy(c.TDXFinanceInfoResponse.Code[:], b[pos+1:pos+7])
+	pos += 7
+
+	var raw financeInfoBlock
+	binary.Read(bytes.NewBuffer(b[pos:]), binary.LittleEndian, &raw)
+	c.Ltgb = float64(raw.Ltgb) * 10000
+	c.Province = raw.Province
+	c.Industry = raw.Industry
+	c.UpdatedDate = getdate(raw.UpdatedDate)
+	c.IPODate = getdate(raw.IPODate)
+
+	fields := []*float64{&c.Zgb, &c.Gjg, &c.Fqrfrg, &c.Frg, &c.Bg, &c.Hg, &c.Zgg,
+		&c.Zzc, &c.Ldzc, &c.Gdzc, &c.Wxzc, &c.Gdrs, &c.Ldfc, &c.Cqfc, &c.Zbgjj, &c.Jzc,
+		&c.Zysr, &c.Zylr, &c.Yszk, &c.Yylr, &c.Tzsy, &c.Jyxjl, &c.Zxjl, &c.Ch,
+		&c.Lrzh, &c.Shlr, &c.Jlr, &c.Wflr, &c.Bl1, &c.Bl2}
+	for i, f := range fields {
+		*f = float64(raw.Values[i])
+		// 股东人数和保留字段不是以万为单位
+		if f != &c.Gdrs && f != &c.Bl1 && f != &c.Bl2 {
+			*f *= 10000
+		}
+	}
 	return nil
 }
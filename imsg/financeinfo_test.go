@@ -0,0 +1,94 @@
+package imsg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// financeInfoBody 构造财务信息响应体, values 依次为总股本到保留2
+func financeInfoBody(market uint8, code string, ltgb float32, ipo, updated uint32, values [30]float32) []byte {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, uint16(1))
+	buf.WriteByte(market)
+	buf.WriteString(code)
+	binary.Write(buf, binary.LittleEndian, financeInfoBlock{
+		Ltgb:        ltgb,
+		Province:    18,
+		Industry:    25,
+		UpdatedDate: updated,
+		IPODate:     ipo,
+		Values:      values,
+	})
+	return buf.Bytes()
+}
+
+func TestTDXFinanceInfoMessage_UnSerialize(t *testing.T) {
+	var values [30]float32
+	for i := range values {
+		values[i] = float32(i + 1)
+	}
+	body := financeInfoBody(MARKET_SH, "600004", 236665.3, 20030428, 20210430, values)
+	if len(body) != 2+7+136 {
+		t.Fatalf("body length %d, want %d", len(body), 2+7+136)
+	}
+
+	msg := NewTDXFinanceInfoMessage(TDXFinanceInfoRequest{})
+	if err := msg.UnSerialize(TDXRespHeader{}, body); err != nil {
+		t.Fatal(err)
+	}
+	rsp := msg.TDXFinanceInfoResponse
+	if rsp.Market != MARKET_SH || string(rsp.Code[:]) != "600004" {
+		t.Errorf("security = %d %s", rsp.Market, rsp.Code)
+	}
+	if rsp.Ltgb != float64(float32(236665.3))*10000 {
+		t.Errorf("Ltgb = %v", rsp.Ltgb)
+	}
+	if rsp.Province != 18 || rsp.Industry != 25 {
+		t.Errorf("Province/Industry = %d/%d", rsp.Province, rsp.Industry)
+	}
+	if want := time.Date(2003, 4, 28, 0, 0, 0, 0, ChinaLocation); !rsp.IPODate.Equal(want) {
+		t.Errorf("IPODate = %v, want %v", rsp.IPODate, want)
+	}
+	if want := time.Date(2021, 4, 30, 0, 0, 0, 0, ChinaLocation); !rsp.UpdatedDate.Equal(want) {
+		t.Errorf("UpdatedDate = %v, want %v", rsp.UpdatedDate, want)
+	}
+	checks := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"Zgb", rsp.Zgb, 10000},
+		{"Zgg", rsp.Zgg, 70000},
+		{"Zzc", rsp.Zzc, 80000},
+		{"Gdrs", rsp.Gdrs, 12},
+		{"Jzc", rsp.Jzc, 160000},
+		{"Jlr", rsp.Jlr, 270000},
+		{"Wflr", rsp.Wflr, 280000},
+		{"Bl1", rsp.Bl1, 29},
+		{"Bl2", rsp.Bl2, 30},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+}
+
+func TestTDXFinanceInfoMessage_NoDate(t *testing.T) {
+	msg := NewTDXFinanceInfoMessage(TDXFinanceInfoRequest{})
+	if err := msg.UnSerialize(TDXRespHeader{}, financeInfoBody(MARKET_SZ, "000001", 0, 0, 0, [30]float32{})); err != nil {
+		t.Fatal(err)
+	}
+	if !msg.IPODate.IsZero() {
+		t.Errorf("IPODate = %v, want zero", msg.IPODate)
+	}
+}
+
+func TestTDXFinanceInfoMessage_Short(t *testing.T) {
+	msg := NewTDXFinanceInfoMessage(TDXFinanceInfoRequest{})
+	if err := msg.UnSerialize(TDXRespHeader{}, []byte{1, 0, 1, '6'}); err == nil {
+		t.Error("expected error for truncated body")
+	}
+}
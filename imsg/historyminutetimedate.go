@@ -35,11 +35,7 @@ type TDXHistoryMinuteTimeDateMessage struct {
 }
 
 func NewTDXHistoryMinuteTimeDateMessage(req TDXHistoryMinuteTimeDateRequest) *TDXHistoryMinuteTimeDateMessage {
-	msg := GetMessage(KMSG_HISTORYMINUTETIMEDATE)
-	if (msg == nil) {
-		Register(KMSG_HISTORYMINUTETIMEDATE, new(TDXHistoryMinuteTimeDateMessage))
-	}
-	sub := GetMessage(KMSG_HISTORYMINUTETIMEDATE).(*TDXHistoryMinuteTimeDateMessage)
+	sub := new(TDXHistoryMinuteTimeDateMessage)
 	sub.TDXHistoryMinuteTimeDateRequest = req
 	sub.TDXReqHeader = TDXReqHeader{0x0c, SeqID(), 0,
 		0x0d, 0x0d, KMSG_HISTORYMINUTETIMEDATE}
@@ -76,4 +72,3 @@ func (c* TDXHistoryMinuteTimeDateMessage) UnSerialize(header interface{}, b []by
 	}
 	return nil
 }
-
@@ -35,11 +35,7 @@ type TDXHistoryTransactionDataMessage struct {
 }
 
 func NewTDXHistoryTransactionDataMessage(req TDXHistoryTransactionDataRequest) *TDXHistoryTransactionDataMessage {
-	msg := GetMessage(KMSG_HISTORYTRANSACTIONDATA)
-	if (msg == nil) {
-		Register(KMSG_HISTORYTRANSACTIONDATA, new(TDXHistoryTransactionDataMessage))
-	}
-	sub := GetMessage(KMSG_HISTORYTRANSACTIONDATA).(*TDXHistoryTransactionDataMessage)
+	sub := new(TDXHistoryTransactionDataMessage)
 	sub.TDXHistoryTransactionDataRequest = req
 	sub.TDXReqHeader = TDXReqHeader{0x0c, SeqID(), 0,
 		0x12, 0x12, KMSG_HISTORYTRANSACTIONDATA}
@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
+	"time"
 )
 
 type TDXIndexBarsRequest struct {
@@ -41,8 +42,13 @@ type IndexBarsElement struct {
 	Hour      int
 	Minute    int
 	DateTime  string
-	UpCount   uint16
-	DownCount uint16
+	UpCount   uint16 // 上涨家数
+	DownCount uint16 // 下跌家数
+}
+
+// Time K线时间, 北京时间
+func (e IndexBarsElement) Time() time.Time {
+	return time.Date(e.Year, time.Month(e.Month), e.Day, e.Hour, e.Minute, 0, 0, ChinaLocation)
 }
 
 type TDXIndexBarsResponse struct {
@@ -59,11 +65,7 @@ type TDXIndexBarsMessage struct {
 }
 
 func NewTDXIndexBarsMessage(req TDXIndexBarsRequest) *TDXIndexBarsMessage {
-	msg := GetMessage(KMSG_INDEXBARS)
-	if (msg == nil) {
-		Register(KMSG_INDEXBARS, new(TDXIndexBarsMessage))
-	}
-	sub := GetMessage(KMSG_INDEXBARS).(*TDXIndexBarsMessage)
+	sub := new(TDXIndexBarsMessage)
 	sub.TDXIndexBarsRequest = req
 	sub.Content = "00000000000000000000"
 	sub.TDXReqHeader = TDXReqHeader{0x0c, SeqID(), 0,
@@ -92,14 +94,9 @@ func (c *TDXIndexBarsMessage) UnSerialize(header interface{}, b []byte) error {
 	pos += 2
 
 	pre_diff_base := 0
-	lasttime := ""
 	for index := uint16(0); index < c.Num; index++ {
 		ele := IndexBarsElement{}
-		if index == 0 {
-			ele.Year, ele.Month, ele.Day, ele.Hour, ele.Minute = getdatetime(int(c.Catecory), b, &pos)
-		} else {
-			ele.Year, ele.Month, ele.Day, ele.Hour, ele.Minute = getdatetimenow(int(c.Catecory), lasttime)
-		}
+		ele.Year, ele.Month, ele.Day, ele.Hour, ele.Minute = getdatetime(int(c.Catecory), b, &pos)
 		ele.DateTime = fmt.Sprintf("%d-%02d-%02d %02d:%02d:00", ele.Year, ele.Month, ele.Day, ele.Hour, ele.Minute)
 
 		price_open_diff := getprice(b, &pos)
@@ -118,7 +115,8 @@ func (c *TDXIndexBarsMessage) UnSerialize(header interface{}, b []byte) error {
 		ele.Amount = getvolume(int(dbvol))
 		pos += 4
 
-		if index != c.TDXIndexBarsResponse.Num-1 {
+		// 最后一根K线可能不带涨跌家数
+		if pos+4 <= len(b) {
 			binary.Read(bytes.NewBuffer(b[pos:pos+2]), binary.LittleEndian, &ele.UpCount)
 			pos += 2
 			binary.Read(bytes.NewBuffer(b[pos:pos+2]), binary.LittleEndian, &ele.DownCount)
@@ -133,7 +131,6 @@ func (c *TDXIndexBarsMessage) UnSerialize(header interface{}, b []byte) error {
 		ele.Low = float64(price_open_diff+price_low_diff) / 1000.0
 
 		pre_diff_base = price_open_diff + price_close_diff
-		lasttime = ele.DateTime
 
 		c.List = append(c.List, ele)
 	}
@@ -0,0 +1,85 @@
+package imsg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTDXIndexBarsMessage_UnSerialize(t *testing.T) {
+	// 午休前后的两根 1 分钟K线和次周一开盘的一根, 每根都带自己的时间
+	times := []time.Time{
+		time.Date(2021, 1, 8, 11, 30, 0, 0, ChinaLocation),
+		time.Date(2021, 1, 8, 13, 1, 0, 0, ChinaLocation),
+		time.Date(2021, 1, 11, 9, 31, 0, 0, ChinaLocation),
+	}
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, uint16(len(times)))
+	for i, tm := range times {
+		zipday := uint16(tm.Year()-2004)<<11 | uint16(int(tm.Month())*100+tm.Day())
+		binary.Write(buf, binary.LittleEndian, zipday)
+		binary.Write(buf, binary.LittleEndian, uint16(tm.Hour()*60+tm.Minute()))
+		if i == 0 {
+			putprice(buf, 3500000)
+		} else {
+			putprice(buf, 0)
+		}
+		putprice(buf, 1000)
+		putprice(buf, 1500)
+		putprice(buf, -500)
+		binary.Write(buf, binary.LittleEndian, math.Float32bits(100))
+		binary.Write(buf, binary.LittleEndian, math.Float32bits(1000))
+		binary.Write(buf, binary.LittleEndian, uint16(800+i))
+		binary.Write(buf, binary.LittleEndian, uint16(700+i))
+	}
+
+	msg := NewTDXIndexBarsMessage(NewTDXIndexBarsRequest(MARKET_SH, "000001", KLINE_TYPE_1MIN, 0, 3))
+	if err := msg.UnSerialize(TDXRespHeader{}, buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if len(msg.List) != len(times) {
+		t.Fatalf("got %d bars, want %d", len(msg.List), len(times))
+	}
+	for i, bar := range msg.List {
+		if !bar.Time().Equal(times[i]) {
+			t.Errorf("bar %d at %v, want %v", i, bar.Time(), times[i])
+		}
+		if bar.UpCount != uint16(800+i) || bar.DownCount != uint16(700+i) {
+			t.Errorf("bar %d up/down = %d/%d", i, bar.UpCount, bar.DownCount)
+		}
+	}
+	if bar := msg.List[2]; bar.Open != 3502 || bar.Close != 3503 || bar.High != 3503.5 {
+		t.Errorf("bar 2 = %+v", bar)
+	}
+	if msg.List[1].DateTime != "2021-01-08 13:01:00" {
+		t.Errorf("DateTime = %q", msg.List[1].DateTime)
+	}
+}
+
+func TestTDXIndexBarsMessage_UnSerializeDaily(t *testing.T) {
+	// 跨周末和元旦假期的日K线, 最后一根不带涨跌家数
+	days := []uint32{20201231, 20210104}
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, uint16(len(days)))
+	for i, d := range days {
+		binary.Write(buf, binary.LittleEndian, d)
+		for j := 0; j < 4; j++ {
+			putprice(buf, 0)
+		}
+		buf.Write(make([]byte, 8))
+		if i < len(days)-1 {
+			buf.Write(make([]byte, 4))
+		}
+	}
+
+	msg := NewTDXIndexBarsMessage(NewTDXIndexBarsRequest(MARKET_SH, "000001", KLINE_TYPE_DAILY, 0, 2))
+	if err := msg.UnSerialize(TDXRespHeader{}, buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2021, 1, 4, 15, 0, 0, 0, ChinaLocation)
+	if len(msg.List) != 2 || !msg.List[1].Time().Equal(want) {
+		t.Fatalf("got %+v, want second bar at %v", msg.List, want)
+	}
+}
@@ -3,33 +3,13 @@ package imsg
 import (
 	"bytes"
 	"encoding/binary"
-	"fmt"
+	"github.com/axgle/mahonia"
 	"go.uber.org/atomic"
 	"math"
 	"net"
 	"time"
 )
 
-var (
-	messageRegistry map[int32]Message
-)
-
-func Register(msgType int32, msg Message) {
-	if _, ok := messageRegistry[msgType]; ok {
-		panic(fmt.Sprintf("trying to register message %d twice", msgType))
-	}
-
-	messageRegistry[msgType] = msg
-}
-
-func GetMessage(msgType int32) Message {
-	entry, ok := messageRegistry[msgType]
-	if !ok {
-		return nil
-	}
-	return entry
-}
-
 // Message represents the structured data that can be handled.
 type Message interface {
 	MessageNumber() int32
@@ -37,8 +17,9 @@ type Message interface {
 	UnSerialize(header interface{}, b []byte) ( error)
 }
 
+// Codec 负责请求编码和响应帧读取, Decode 返回响应头和解压后的响应体
 type Codec interface {
-	Decode(net.Conn) (Message, error)
+	Decode(net.Conn) (TDXRespHeader, []byte, error)
 	Encode(Message) ([]byte, error)
 }
 
@@ -52,6 +33,7 @@ const (
 	//市场
 	MARKET_SZ = 0 //深圳
 	MARKET_SH = 1 //上海
+	MARKET_BJ = 2 //北京
 
 	/*
 	K线种类
@@ -86,6 +68,8 @@ const (
 	MAX_TRANSACTION_COUNT = 2000
 	// k先数据最多800条
 	MAX_KLINE_COUNT = 800
+	// 行情信息每次最多80只
+	MAX_SECURITY_QUOTES_COUNT = 80
 
 	//板块相关参数
 	BLOCK_ZS      = "block_zs.dat" // 指数
@@ -109,7 +93,7 @@ const (
 	KMSG_FINANCEINFO            = 0x10   // 财务信息
 	KMSG_HISTORYMINUTETIMEDATE  = 0xfb4  // 历史分时信息
 	KMSG_HISTORYTRANSACTIONDATA = 0xfb5  // 历史分笔成交信息
-	KMSG_INDEXBARS              = 0x52d  // 指数K线
+	KMSG_INDEXBARS              = 0x52d  // 指数K线, 股票K线也使用该命令
 	KMSG_MINUTETIMEDATA         = 0x537  // 分时数据
 	KMSG_SECURITYLIST           = 0x450  // 证券列表
 	KMSG_SECURITYQUOTES			= 0x53e  // 行情信息
@@ -117,6 +101,22 @@ const (
 	KMSG_XDXRINFO				= 0x0f	 // 除权除息信息
 )
 
+const (
+	KMSG_EXSETUP           = 0x2454 // 拓展行情建立链接
+	KMSG_EXMARKETS         = 0x23f4 // 拓展市场列表
+	KMSG_EXINSTRUMENTCOUNT = 0x23f0 // 拓展品种数量
+	KMSG_EXINSTRUMENTINFO  = 0x23f5 // 拓展品种列表
+	KMSG_EXINSTRUMENTQUOTE = 0x23fa // 拓展行情信息
+	KMSG_EXINSTRUMENTBARS  = 0x23ff // 拓展K线
+	KMSG_EXMINUTETIMEDATA  = 0x240b // 拓展分时数据
+	KMSG_EXTRANSACTIONDATA = 0x23fc // 拓展分笔成交信息
+
+	KMSG_EXHISTORYINSTRUMENTBARSRANGE = 0x240d // 拓展历史K线区间
+	KMSG_EXINSTRUMENTQUOTELIST        = 0x2400 // 拓展行情列表
+	KMSG_EXHISTORYMINUTETIMEDATA      = 0x240c // 拓展历史分时信息
+	KMSG_EXHISTORYTRANSACTIONDATA     = 0x2406 // 拓展历史分笔成交信息
+)
+
 type TDXReqHeader struct {
 	I1      uint8
 	SeqID   uint32
@@ -173,6 +173,16 @@ func getprice(b []byte, pos *int) int {
 	return intdata
 }
 
+// checkprice 判断 pos 处是否有完整的变长整数
+func checkprice(b []byte, pos int) bool {
+	for ; pos < len(b); pos++ {
+		if b[pos]&0x80 == 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func gettime(b [] byte, pos *int) (h uint16, m uint16) {
 	var sec uint16
 	binary.Read(bytes.NewBuffer(b[*pos:*pos+2]), binary.LittleEndian, &sec)
@@ -207,59 +217,30 @@ func getdatetime(category int, b [] byte, pos *int) (year int, month int, day in
 	return
 }
 
-func getdatetimenow(category int, lasttime string) (year int, month int, day int, hour int, minute int) {
-	utime, _ := time.Parse("2006-01-02 15:04:05", lasttime)
-	switch category {
-	case KLINE_TYPE_5MIN:
-		utime = utime.Add(time.Minute * 5)
-	case KLINE_TYPE_15MIN:
-		utime = utime.Add(time.Minute * 15)
-	case KLINE_TYPE_30MIN:
-		utime = utime.Add(time.Minute * 30)
-	case KLINE_TYPE_1HOUR:
-		utime = utime.Add(time.Hour)
-	case KLINE_TYPE_DAILY:
-		utime = utime.AddDate(0, 0, 1)
-	case KLINE_TYPE_WEEKLY:
-		utime = utime.Add(time.Hour * 24 * 7)
-	case KLINE_TYPE_MONTHLY:
-		utime = utime.AddDate(0, 1, 0)
-	case KLINE_TYPE_EXHQ_1MIN:
-		utime = utime.Add(time.Minute)
-	case KLINE_TYPE_1MIN:
-		utime = utime.Add(time.Minute)
-	case KLINE_TYPE_RI_K:
-		utime = utime.AddDate(0, 0, 1)
-	case KLINE_TYPE_3MONTH:
-		utime = utime.AddDate(0, 3, 0)
-	case KLINE_TYPE_YEARLY:
-		utime = utime.AddDate(1, 0, 0)
+// getgbkstr 取 0 结尾的 GBK 字符串
+func getgbkstr(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
 	}
+	return mahonia.NewDecoder("gbk").ConvertString(string(b))
+}
 
-	if category < 4 || category == 7 || category == 8 {
-		if ((utime.Hour() >= 15 && utime.Minute() > 0) || (utime.Hour() > 15)) {
-			utime = utime.AddDate(0, 0, 1)
-			utime = utime.Add(time.Minute * 30)
-			hour = (utime.Hour() + 18) % 24
-		} else {
-			hour = utime.Hour()
-		}
-		minute = utime.Minute()
-	} else {
-		if (utime.Unix() > time.Now().Unix()) {
-			utime = time.Now()
-		}
-		hour = utime.Hour()
-		minute = utime.Minute()
-		if (utime.Hour() > 15) {
-			hour = 15
-			minute = 0
-		}
+// ChinaLocation 交易所所在时区, 没有时区数据库时使用 UTC+8
+var ChinaLocation = loadChinaLocation()
+
+func loadChinaLocation() *time.Location {
+	if loc, err := time.LoadLocation("Asia/Shanghai"); err == nil {
+		return loc
 	}
-	year = utime.Year()
-	month = int(utime.Month())
-	day = utime.Day()
-	return
+	return time.FixedZone("CST", 8*3600)
+}
+
+// getdate 把 yyyymmdd 格式的日期转为 time.Time, 0 表示没有日期
+func getdate(d uint32) time.Time {
+	if d == 0 {
+		return time.Time{}
+	}
+	return time.Date(int(d/10000), time.Month(d%10000/100), int(d%100), 0, 0, 0, 0, ChinaLocation)
 }
 
 func getvolume(ivol int) (volume float64) {
@@ -321,6 +302,5 @@ func getvolume(ivol int) (volume float64) {
 }
 
 func init() {
-	messageRegistry = make(map[int32]Message)
 	Seq_ID = atomic.NewUint32(1)
 }
@@ -38,11 +38,7 @@ type TDXMinuteTimeDataMessage struct {
 }
 
 func NewTDXMinuteTimeDataMessage(req TDXMinuteTimeDataRequest) *TDXMinuteTimeDataMessage {
-	msg := GetMessage(KMSG_MINUTETIMEDATA)
-	if (msg == nil) {
-		Register(KMSG_MINUTETIMEDATA, new(TDXMinuteTimeDataMessage))
-	}
-	sub := GetMessage(KMSG_MINUTETIMEDATA).(*TDXMinuteTimeDataMessage)
+	sub := new(TDXMinuteTimeDataMessage)
 	sub.TDXMinuteTimeDataRequest = req
 	sub.TDXReqHeader = TDXReqHeader{0x0c, SeqID(), 0,
 		0xe, 0xe, KMSG_MINUTETIMEDATA}
@@ -50,7 +46,7 @@ func NewTDXMinuteTimeDataMessage(req TDXMinuteTimeDataRequest) *TDXMinuteTimeDat
 }
 
 func (c *TDXMinuteTimeDataMessage) MessageNumber() int32 {
-	return KMSG_INDEXBARS
+	return KMSG_MINUTETIMEDATA
 }
 
 func (c *TDXMinuteTimeDataMessage) Serialize() ([]byte, error) {
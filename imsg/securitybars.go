@@ -0,0 +1,143 @@
+package imsg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// 股票、基金K线. 与指数K线使用同一命令 KMSG_INDEXBARS, 服务器按代码决定返回的格式:
+// 股票K线没有涨跌家数. 响应按发出的请求消息解码.
+type TDXSecurityBarsRequest struct {
+	Market   uint16
+	Code     [6]byte
+	Category uint16 // K线种类 KLINE_TYPE_*
+	I        uint16 // 未知
+	Start    uint16
+	Count    uint16
+}
+
+func NewTDXSecurityBarsRequest(market uint16, code string, category uint16, start uint16, count uint16) TDXSecurityBarsRequest {
+	req := TDXSecurityBarsRequest{
+		Market:   market,
+		Category: category,
+		Start:    start,
+		Count:    count,
+	}
+	req.I = 1
+	copy(req.Code[:], []byte(code)[:])
+	return req
+}
+
+type SecurityBarsElement struct {
+	Open     float64
+	Close    float64
+	High     float64
+	Low      float64
+	Vol      float64 // 成交量
+	Amount   float64 // 成交额
+	Year     int
+	Month    int
+	Day      int
+	Hour     int
+	Minute   int
+	DateTime string
+}
+
+// Time K线时间, 北京时间
+func (e SecurityBarsElement) Time() time.Time {
+	return time.Date(e.Year, time.Month(e.Month), e.Day, e.Hour, e.Minute, 0, 0, ChinaLocation)
+}
+
+type TDXSecurityBarsResponse struct {
+	Num  uint16
+	List []SecurityBarsElement
+}
+
+type TDXSecurityBarsMessage struct {
+	TDXReqHeader
+	TDXSecurityBarsRequest
+	Content string
+	TDXRespHeader
+	TDXSecurityBarsResponse
+}
+
+func NewTDXSecurityBarsMessage(req TDXSecurityBarsRequest) *TDXSecurityBarsMessage {
+	sub := new(TDXSecurityBarsMessage)
+	sub.TDXSecurityBarsRequest = req
+	sub.Content = "00000000000000000000"
+	sub.TDXReqHeader = TDXReqHeader{0x0c, SeqID(), 0,
+		0x1c, 0x1c, KMSG_INDEXBARS}
+	return sub
+}
+
+func (c *TDXSecurityBarsMessage) MessageNumber() int32 {
+	return KMSG_INDEXBARS
+}
+
+func (c *TDXSecurityBarsMessage) Serialize() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, c.TDXReqHeader)
+	binary.Write(buf, binary.LittleEndian, c.TDXSecurityBarsRequest)
+	b, err := hex.DecodeString(c.Content)
+	buf.Write(b)
+	return buf.Bytes(), err
+}
+
+func (c *TDXSecurityBarsMessage) UnSerialize(header interface{}, b []byte) error {
+	h := header.(TDXRespHeader)
+	c.TDXRespHeader = h
+	pos := 0
+	if len(b) < 2 {
+		return fmt.Errorf("security bars: response truncated, %d bytes", len(b))
+	}
+	binary.Read(bytes.NewBuffer(b[pos:pos+2]), binary.LittleEndian, &c.Num)
+	pos += 2
+
+	pre_diff_base := 0
+	c.List = make([]SecurityBarsElement, 0, c.Num)
+	for index := uint16(0); index < c.Num; index++ {
+		// 日期4 价格4个变长整数 成交量4 成交额4
+		if len(b) < pos+4 {
+			return fmt.Errorf("security bars: bar %d of %d truncated", index, c.Num)
+		}
+		ele := SecurityBarsElement{}
+		ele.Year, ele.Month, ele.Day, ele.Hour, ele.Minute = getdatetime(int(c.Category), b, &pos)
+		ele.DateTime = fmt.Sprintf("%d-%02d-%02d %02d:%02d:00", ele.Year, ele.Month, ele.Day, ele.Hour, ele.Minute)
+
+		var diffs [4]int
+		for i := range diffs {
+			if !checkprice(b, pos) {
+				return fmt.Errorf("security bars: bar %d of %d truncated", index, c.Num)
+			}
+			diffs[i] = getprice(b, &pos)
+		}
+		price_open_diff, price_close_diff, price_high_diff, price_low_diff := diffs[0], diffs[1], diffs[2], diffs[3]
+
+		if len(b) < pos+8 {
+			return fmt.Errorf("security bars: bar %d of %d truncated", index, c.Num)
+		}
+		var ivol uint32
+		binary.Read(bytes.NewBuffer(b[pos:pos+4]), binary.LittleEndian, &ivol)
+		ele.Vol = getvolume(int(ivol))
+		pos += 4
+
+		var dbvol uint32
+		binary.Read(bytes.NewBuffer(b[pos:pos+4]), binary.LittleEndian, &dbvol)
+		ele.Amount = getvolume(int(dbvol))
+		pos += 4
+
+		price_open_diff += pre_diff_base
+		ele.Open = float64(price_open_diff) / 1000.0
+		ele.Close = float64(price_open_diff+price_close_diff) / 1000.0
+		ele.High = float64(price_open_diff+price_high_diff) / 1000.0
+		ele.Low = float64(price_open_diff+price_low_diff) / 1000.0
+
+		pre_diff_base = price_open_diff + price_close_diff
+
+		c.List = append(c.List, ele)
+	}
+	return nil
+}
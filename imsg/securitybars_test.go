@@ -0,0 +1,107 @@
+package imsg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// putprice 按 getprice 的变长格式写入有符号整数
+func putprice(buf *bytes.Buffer, v int) {
+	sign := byte(0)
+	if v < 0 {
+		sign = 0x40
+		v = -v
+	}
+	b := byte(v&0x3f) | sign
+	v >>= 6
+	for v > 0 {
+		buf.WriteByte(b | 0x80)
+		b = byte(v & 0x7f)
+		v >>= 7
+	}
+	buf.WriteByte(b)
+}
+
+func TestTDXSecurityBarsMessage_UnSerialize(t *testing.T) {
+	// 两根日K线: 10.50/10.80/10.90/10.40, 10.80/10.60/10.85/10.55
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, uint16(2))
+	binary.Write(buf, binary.LittleEndian, uint32(20210104))
+	for _, v := range []int{10500, 300, 400, -100} {
+		putprice(buf, v)
+	}
+	binary.Write(buf, binary.LittleEndian, math.Float32bits(123456))
+	binary.Write(buf, binary.LittleEndian, math.Float32bits(1.296e6))
+	binary.Write(buf, binary.LittleEndian, uint32(20210105))
+	for _, v := range []int{0, -200, 50, -250} {
+		putprice(buf, v)
+	}
+	binary.Write(buf, binary.LittleEndian, math.Float32bits(1000))
+	binary.Write(buf, binary.LittleEndian, math.Float32bits(10600))
+
+	msg := NewTDXSecurityBarsMessage(NewTDXSecurityBarsRequest(MARKET_SH, "600000", KLINE_TYPE_DAILY, 0, 2))
+	if err := msg.UnSerialize(TDXRespHeader{}, buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	want := []SecurityBarsElement{
+		{Open: 10.5, Close: 10.8, High: 10.9, Low: 10.4, Vol: 123456, Amount: 1.296e6,
+			Year: 2021, Month: 1, Day: 4, Hour: 15, DateTime: "2021-01-04 15:00:00"},
+		{Open: 10.8, Close: 10.6, High: 10.85, Low: 10.55, Vol: 1000, Amount: 10600,
+			Year: 2021, Month: 1, Day: 5, Hour: 15, DateTime: "2021-01-05 15:00:00"},
+	}
+	if len(msg.List) != len(want) {
+		t.Fatalf("got %d bars, want %d", len(msg.List), len(want))
+	}
+	for i, w := range want {
+		if msg.List[i] != w {
+			t.Errorf("bar %d = %+v, want %+v", i, msg.List[i], w)
+		}
+	}
+}
+
+func TestTDXSecurityBarsMessage_MinuteBars(t *testing.T) {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, uint16(1))
+	// 2021-03-15 09:35, 日期压缩为 (年-2004)<<11 + 月*100 + 日
+	binary.Write(buf, binary.LittleEndian, uint16((2021-2004)<<11+315))
+	binary.Write(buf, binary.LittleEndian, uint16(9*60+35))
+	for _, v := range []int{3456, 4, 10, -2} {
+		putprice(buf, v)
+	}
+	binary.Write(buf, binary.LittleEndian, math.Float32bits(1000))
+	binary.Write(buf, binary.LittleEndian, math.Float32bits(3460))
+
+	msg := NewTDXSecurityBarsMessage(NewTDXSecurityBarsRequest(MARKET_SH, "510300", KLINE_TYPE_5MIN, 0, 1))
+	if err := msg.UnSerialize(TDXRespHeader{}, buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	bar := msg.List[0]
+	if bar.DateTime != "2021-03-15 09:35:00" {
+		t.Errorf("DateTime = %s", bar.DateTime)
+	}
+	if bar.Open != 3.456 || bar.Close != 3.46 || bar.High != 3.466 || bar.Low != 3.454 {
+		t.Errorf("prices = %+v", bar)
+	}
+}
+
+func TestTDXSecurityBarsMessage_Truncated(t *testing.T) {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, uint16(1))
+	binary.Write(buf, binary.LittleEndian, uint32(20210104))
+	for _, v := range []int{10500, 300, 400, -100} {
+		putprice(buf, v)
+	}
+	binary.Write(buf, binary.LittleEndian, math.Float32bits(123456))
+	binary.Write(buf, binary.LittleEndian, math.Float32bits(1.296e6))
+	full := buf.Bytes()
+
+	// 直接调用 UnSerialize, 截断的数据必须返回错误而不是越界
+	for n := 0; n < len(full); n++ {
+		msg := NewTDXSecurityBarsMessage(NewTDXSecurityBarsRequest(MARKET_SH, "600000", KLINE_TYPE_DAILY, 0, 1))
+		if err := msg.UnSerialize(TDXRespHeader{}, full[:n]); err == nil {
+			t.Errorf("%d of %d bytes: expected error", n, len(full))
+		}
+	}
+}
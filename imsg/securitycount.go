@@ -23,11 +23,7 @@ type TDXSecurityCountMessage struct {
 }
 
 func NewTDXSecurityCountMessage(req TDXSecurityCountRequest) *TDXSecurityCountMessage {
-	msg := GetMessage(KMSG_SECURITYCOUNT)
-	if (msg == nil) {
-		Register(KMSG_SECURITYCOUNT, new(TDXSecurityCountMessage))
-	}
-	sub := GetMessage(KMSG_SECURITYCOUNT).(*TDXSecurityCountMessage)
+	sub := new(TDXSecurityCountMessage)
 	sub.TDXSecurityCountRequest = req
 	sub.Content = "75c73301"
 	sub.TDXReqHeader = TDXReqHeader{0x0c, SeqID(), 0,
@@ -53,4 +49,4 @@ func (c* TDXSecurityCountMessage) UnSerialize(header interface{}, b []byte) erro
 	c.TDXRespHeader = h
 	binary.Read(bytes.NewBuffer(b), binary.LittleEndian, &c.TDXSecurityCountResponse)
 	return nil
-}
\ No newline at end of file
+}
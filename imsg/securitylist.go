@@ -32,11 +32,7 @@ type TDXSecurityListMessage struct {
 }
 
 func NewTDXSecurityListMessage(req TDXSecurityListRequest) *TDXSecurityListMessage {
-	msg := GetMessage(KMSG_SECURITYLIST)
-	if (msg == nil) {
-		Register(KMSG_SECURITYLIST, new(TDXSecurityListMessage))
-	}
-	sub := GetMessage(KMSG_SECURITYLIST).(*TDXSecurityListMessage)
+	sub := new(TDXSecurityListMessage)
 	sub.TDXSecurityListRequest = req
 	sub.TDXReqHeader = TDXReqHeader{0x0c, SeqID(), 0,
 		0x06, 0x06, KMSG_SECURITYLIST}
@@ -4,8 +4,9 @@ import (
 	"bytes"
 	"encoding/binary"
 	"encoding/hex"
-	"fmt"
 	"github.com/axgle/mahonia"
+	"math"
+	"time"
 )
 
 type Level struct {
@@ -15,20 +16,21 @@ type Level struct {
 type SecurityQuotesElement struct {
 	Market         uint8
 	Code          	string
-	Active1        uint16
+	Active1        uint16 // 活跃度, 行情每变化一次加一
 	Price          float64
 	LastClose      float64
 	Open           float64
 	High           float64
 	Low            float64
-	ServerTime     string
-	ReversedBytes0 int
-	ReversedBytes1 int
-	Vol            int
-	CurVol         int
-	Amount         float64
-	SVol           int
-	BVol           int
+	ReversedBytes0 int     // 服务器时间原始值, 见 ServerTime
+	ReversedBytes1 int     // 通常为现价原始值的相反数
+	Vol            int     // 总量(手)
+	CurVol         int     // 现量(手)
+	Amount         float64 // 总金额(元)
+	SVol           int     // 内盘, 主动卖成交量(手)
+	BVol           int     // 外盘, 主动买成交量(手)
+	// ReversedBytes2 到 ReversedBytes8 含义未知, 与 pytdx 的 reversed_bytes2-8 对应, 保留原始值.
+	// 除 ReversedBytes4 为 uint16 外都是变长整数, 见 getprice.
 	ReversedBytes2 int
 	ReversedBytes3 int
 	BidLevels      []Level
@@ -38,8 +40,8 @@ type SecurityQuotesElement struct {
 	ReversedBytes6 int
 	ReversedBytes7 int
 	ReversedBytes8 int
-	ReversedBytes9 float64
-	Active2        uint16
+	Speed          float64 // 涨速(%), 最近若干分钟的涨幅
+	Active2        uint16  // 活跃度, 与 Active1 相同时本条行情完整
 }
 
 type TDXSecurityQuotesResponse struct {
@@ -65,11 +67,7 @@ type TDXSecurityQuotesMessage struct {
 }
 
 func NewTDXSecurityQuotesMessage(req TDXSecurityQuotesRequest) *TDXSecurityQuotesMessage {
-	msg := GetMessage(KMSG_SECURITYQUOTES)
-	if (msg == nil) {
-		Register(KMSG_SECURITYQUOTES, new(TDXSecurityQuotesMessage))
-	}
-	sub := GetMessage(KMSG_SECURITYQUOTES).(*TDXSecurityQuotesMessage)
+	sub := new(TDXSecurityQuotesMessage)
 	sub.TDXSecurityQuotesRequest = req
 	sub.Content = "0500000000000000"
 	pkglen := uint16(len(req.List)*7 + 12)
@@ -122,7 +120,6 @@ func (c *TDXSecurityQuotesMessage) UnSerialize(header interface{}, b []byte) err
 		ele.Low = c.getprice(price, getprice(b, &pos))
 
 		ele.ReversedBytes0 = getprice(b, &pos)
-		ele.ServerTime = fmt.Sprintf("%d", ele.ReversedBytes0)
 		ele.ReversedBytes1 = getprice(b, &pos)
 
 		ele.Vol = getprice(b, &pos)
@@ -154,10 +151,10 @@ func (c *TDXSecurityQuotesMessage) UnSerialize(header interface{}, b []byte) err
 		ele.ReversedBytes7 = getprice(b, &pos)
 		ele.ReversedBytes8 = getprice(b, &pos)
 
-		var reversedbytes9 int16
-		binary.Read(bytes.NewBuffer(b[pos:pos+2]), binary.LittleEndian, &reversedbytes9)
+		var speed int16
+		binary.Read(bytes.NewBuffer(b[pos:pos+2]), binary.LittleEndian, &speed)
 		pos += 2
-		ele.ReversedBytes9 = float64(reversedbytes9) / 100.0
+		ele.Speed = float64(speed) / 100.0
 		binary.Read(bytes.NewBuffer(b[pos:pos+2]), binary.LittleEndian, &ele.Active2)
 		pos += 2
 
@@ -169,3 +166,57 @@ func (c *TDXSecurityQuotesMessage) UnSerialize(header interface{}, b []byte) err
 func (c *TDXSecurityQuotesMessage) getprice(price int, diff int) float64 {
 	return float64(price+diff) / 100.0
 }
+
+// ServerTime 服务器行情时间. 行情中只有时刻, 日期取 day 在北京时间的日期,
+// 通常传入收到响应的时间. 没有时间时返回零值.
+func (e SecurityQuotesElement) ServerTime(day time.Time) time.Time {
+	return getservertime(e.ReversedBytes0, day.In(ChinaLocation))
+}
+
+// getservertime 解析行情中的服务器时间, 格式为 HMMxxxx 或 HHMMxxxx:
+// 第 3、4 位小于 60 时为分钟, 后 4 位为万分之一分钟;
+// 否则后 6 位整体为百万分之一小时. 参见 pytdx 的 _format_time.
+func getservertime(raw int, day time.Time) time.Time {
+	if raw <= 0 {
+		return time.Time{}
+	}
+	hour, rest := raw/1000000, raw%1000000
+	var minute int
+	var second float64
+	if rest/10000 < 60 {
+		minute = rest / 10000
+		second = float64(rest%10000) * 60 / 10000
+	} else {
+		minute = rest * 60 / 1000000
+		second = float64(rest*60%1000000) * 60 / 1000000
+	}
+	t := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, day.Location())
+	return t.Add(time.Duration(math.Round(second*1000)) * time.Millisecond)
+}
+
+// Limits 按涨跌幅比例 ratio 计算涨停价和跌停价, 四舍五入到 decimals 位小数.
+// 行情中没有涨跌停价, ratio 由板块规则决定, 可用 security.LimitRatio 按代码取得;
+// decimals 取证券列表中的 SecurityElement.DecimalPoint, 股票为 2, 基金和债券为 3.
+func (e SecurityQuotesElement) Limits(ratio float64, decimals int) (up, down float64) {
+	scale := math.Pow10(decimals)
+	units := e.LastClose * scale
+	up = math.Floor(units*(1+ratio)+0.5+1e-6) / scale
+	down = math.Floor(units*(1-ratio)+0.5+1e-6) / scale
+	return
+}
+
+// TurnoverRate 换手率(%), floatShares 为流通股本(股)
+func (e SecurityQuotesElement) TurnoverRate(floatShares float64) float64 {
+	if floatShares <= 0 {
+		return 0
+	}
+	return float64(e.Vol) * 100 / floatShares * 100
+}
+
+// AvgPrice 均价, 总金额除以总成交股数
+func (e SecurityQuotesElement) AvgPrice() float64 {
+	if e.Vol == 0 {
+		return 0
+	}
+	return e.Amount / float64(e.Vol*100)
+}
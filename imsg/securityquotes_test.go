@@ -0,0 +1,110 @@
+package imsg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestGetServerTime(t *testing.T) {
+	day := time.Date(2021, 1, 4, 0, 0, 0, 0, ChinaLocation)
+	tests := []struct {
+		raw  int
+		want time.Time
+	}{
+		// 第 3、4 位为分钟, 后 4 位为万分之一分钟: 1234 * 60 / 10000 = 7.404 秒
+		{9301234, time.Date(2021, 1, 4, 9, 30, 7, 404e6, ChinaLocation)},
+		{14595000, time.Date(2021, 1, 4, 14, 59, 30, 0, ChinaLocation)},
+		// 后 6 位为百万分之一小时: 999947 * 3.6ms = 59 分 59.809 秒
+		{14999947, time.Date(2021, 1, 4, 14, 59, 59, 809e6, ChinaLocation)},
+		{0, time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := getservertime(tt.raw, day); !got.Equal(tt.want) {
+			t.Errorf("getservertime(%d) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestTDXSecurityQuotesMessage_UnSerialize(t *testing.T) {
+	// 一条行情: 现价 10.50, 昨收 10.00, 开 10.10, 高 10.60, 低 10.05, 时间 14:59:30
+	buf := new(bytes.Buffer)
+	buf.Write([]byte{0xb1, 0xcb})
+	binary.Write(buf, binary.LittleEndian, uint16(1))
+	buf.WriteByte(MARKET_SH)
+	buf.WriteString("600000")
+	binary.Write(buf, binary.LittleEndian, uint16(7))
+	for _, v := range []int{1050, -50, -40, 10, -45, 14595000, -1050, 12000, 30} {
+		putprice(buf, v)
+	}
+	binary.Write(buf, binary.LittleEndian, math.Float32bits(12600000))
+	for _, v := range []int{5000, 7000, 1, 2} {
+		putprice(buf, v)
+	}
+	for i := 0; i < 5; i++ {
+		for _, v := range []int{-1 - i, 1 + i, 100, 200} {
+			putprice(buf, v)
+		}
+	}
+	binary.Write(buf, binary.LittleEndian, uint16(0))
+	for i := 0; i < 4; i++ {
+		putprice(buf, 0)
+	}
+	binary.Write(buf, binary.LittleEndian, int16(-25))
+	binary.Write(buf, binary.LittleEndian, uint16(7))
+
+	msg := new(TDXSecurityQuotesMessage)
+	if err := msg.UnSerialize(TDXRespHeader{}, buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if len(msg.QuotesList) != 1 {
+		t.Fatalf("got %d quotes, want 1", len(msg.QuotesList))
+	}
+	q := msg.QuotesList[0]
+	if q.Code != "600000" || q.Price != 10.5 || q.LastClose != 10 || q.High != 10.6 || q.Low != 10.05 {
+		t.Errorf("prices = %+v", q)
+	}
+	// 日期取传入的时间在北京时间的日期, 与本地时钟无关
+	day := time.Date(2021, 1, 3, 20, 0, 0, 0, time.UTC)
+	if st := q.ServerTime(day); !st.Equal(time.Date(2021, 1, 4, 14, 59, 30, 0, ChinaLocation)) || st.Location() != ChinaLocation {
+		t.Errorf("ServerTime = %v", st)
+	}
+	if q.Vol != 12000 || q.CurVol != 30 || q.Amount != 12600000 || q.SVol != 5000 || q.BVol != 7000 {
+		t.Errorf("volumes = %+v", q)
+	}
+	if q.BidLevels[0].Price != 10.49 || q.OfferLevels[4].Price != 10.55 {
+		t.Errorf("levels = %v / %v", q.BidLevels, q.OfferLevels)
+	}
+	if q.Speed != -0.25 || q.Active1 != q.Active2 {
+		t.Errorf("Speed = %v, Active = %d/%d", q.Speed, q.Active1, q.Active2)
+	}
+	if avg := q.AvgPrice(); avg != 10.5 {
+		t.Errorf("AvgPrice = %v, want 10.5", avg)
+	}
+	if rate := q.TurnoverRate(12e6); rate != 10 {
+		t.Errorf("TurnoverRate = %v, want 10", rate)
+	}
+}
+
+func TestSecurityQuotesElement_Limits(t *testing.T) {
+	tests := []struct {
+		lastClose, ratio float64
+		decimals         int
+		up, down         float64
+	}{
+		{10, 0.1, 2, 11, 9},
+		{10.05, 0.1, 2, 11.06, 9.05},
+		{3.33, 0.05, 2, 3.5, 3.16},
+		{25.47, 0.2, 2, 30.56, 20.38},
+		// 基金价格精确到厘
+		{1.234, 0.1, 3, 1.357, 1.111},
+	}
+	for _, tt := range tests {
+		up, down := SecurityQuotesElement{LastClose: tt.lastClose}.Limits(tt.ratio, tt.decimals)
+		if up != tt.up || down != tt.down {
+			t.Errorf("Limits(%v, %v, %d) = %v, %v, want %v, %v", tt.lastClose, tt.ratio, tt.decimals, up, down, tt.up, tt.down)
+		}
+	}
+}
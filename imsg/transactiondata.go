@@ -26,11 +26,7 @@ type TDXTransactionDataMessage struct {
 }
 
 func NewTDXTransactionDataMessage(req TDXTransactionDataRequest) *TDXTransactionDataMessage {
-	msg := GetMessage(KMSG_TRANSACTIONDATA)
-	if (msg == nil) {
-		Register(KMSG_TRANSACTIONDATA, new(TDXTransactionDataMessage))
-	}
-	sub := GetMessage(KMSG_TRANSACTIONDATA).(*TDXTransactionDataMessage)
+	sub := new(TDXTransactionDataMessage)
 	sub.TDXTransactionDataRequest = req
 	sub.TDXReqHeader = TDXReqHeader{0x0c, SeqID(), 0,
 		0x0e, 0x0e, KMSG_TRANSACTIONDATA}
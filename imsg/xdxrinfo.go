@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"encoding/binary"
 	"encoding/hex"
+	"fmt"
 	"github.com/axgle/mahonia"
+	"time"
 )
 
 var XDXR_CATEGORY_MAPPING = map[uint8]string{
@@ -30,19 +32,20 @@ type XdxrElement struct {
 	Year           int
 	Month          int
 	Day            int
+	Date           time.Time // 除权除息日
 	Category       uint8
 	Describe       string
-	SuoGu          float32
-	SongZhuanGu    float32
-	FenHong        float32
-	PeiGu          float32
-	PeiGuJia       float32
-	PanQianLiuTong float64
-	PanHouLiuTong  float64
-	QianZongGuBen  float64
-	HouZongGuBen   float64
-	FenShu         float32
-	XingQuanJia    float32
+	SuoGu          float32 // 缩股比例
+	SongZhuanGu    float32 // 每10股送转股数
+	FenHong        float32 // 每10股分红(元)
+	PeiGu          float32 // 每10股配股数
+	PeiGuJia       float32 // 配股价
+	PanQianLiuTong float64 // 变动前流通股本(万股)
+	PanHouLiuTong  float64 // 变动后流通股本(万股)
+	QianZongGuBen  float64 // 变动前总股本(万股)
+	HouZongGuBen   float64 // 变动后总股本(万股)
+	FenShu         float32 // 权证份数
+	XingQuanJia    float32 // 行权价
 }
 
 type TDXXdxrInfoResponse struct {
@@ -64,11 +67,7 @@ type TDXXdxrInfoMessage struct {
 }
 
 func NewTDXXdxrInfoMessage(req TDXXdxrInfoRequest) *TDXXdxrInfoMessage {
-	msg := GetMessage(KMSG_XDXRINFO)
-	if (msg == nil) {
-		Register(KMSG_XDXRINFO, new(TDXXdxrInfoMessage))
-	}
-	sub := GetMessage(KMSG_XDXRINFO).(*TDXXdxrInfoMessage)
+	sub := new(TDXXdxrInfoMessage)
 	sub.TDXXdxrInfoRequest = req
 	sub.Content = "0100"
 	sub.TDXReqHeader = TDXReqHeader{0x0c, SeqID(), 0,
@@ -99,57 +98,49 @@ func (c *TDXXdxrInfoMessage) UnSerialize(header interface{}, b []byte) error {
 	pos := 9
 	binary.Read(bytes.NewBuffer(b[pos:pos+2]), binary.LittleEndian, &c.Num)
 	pos += 2
-	for index := uint16(0); index < c.Num; index ++ {
+	enc := mahonia.NewDecoder("gbk")
+	c.List = make([]XdxrElement, 0, c.Num)
+	for index := uint16(0); index < c.Num; index++ {
+		// 市场1 代码6 保留1 日期4 类别1 数据16
+		if len(b) < pos+29 {
+			return fmt.Errorf("xdxr info: record %d of %d truncated", index, c.Num)
+		}
 		ele := XdxrElement{}
-		binary.Read(bytes.NewBuffer(b[pos:pos+1]), binary.LittleEndian, &ele.Market)
+		ele.Market = b[pos]
 		pos += 1
-		var code [6]byte
-		binary.Read(bytes.NewBuffer(b[pos:pos+6]), binary.LittleEndian, &code)
-		enc := mahonia.NewDecoder("gbk")
-		ele.Code = enc.ConvertString(string(code[:]))
+		ele.Code = enc.ConvertString(string(b[pos : pos+6]))
 		pos += 6
 
 		pos += 1
-		ele.Year, ele.Month, ele.Day, _, _ = getdatetime(9, b, &pos)
+		ele.Year, ele.Month, ele.Day, _, _ = getdatetime(KLINE_TYPE_RI_K, b, &pos)
+		ele.Date = time.Date(ele.Year, time.Month(ele.Month), ele.Day, 0, 0, 0, 0, ChinaLocation)
 
-		binary.Read(bytes.NewBuffer(b[pos:pos+1]), binary.LittleEndian, &ele.Category)
+		ele.Category = b[pos]
 		pos += 1
 
-		var panqianliutong_raw, qianzongguben_raw, panhouliutong_raw, houzongguben_raw uint32
-		if (ele.Category == 1) {
-			binary.Read(bytes.NewBuffer(b[pos:pos+4]), binary.LittleEndian, &ele.FenHong)
-			pos += 4
-			binary.Read(bytes.NewBuffer(b[pos:pos+4]), binary.LittleEndian, &ele.PeiGuJia)
-			pos += 4
-			binary.Read(bytes.NewBuffer(b[pos:pos+4]), binary.LittleEndian, &ele.SongZhuanGu)
-			pos += 4
-			binary.Read(bytes.NewBuffer(b[pos:pos+4]), binary.LittleEndian, &ele.PeiGu)
-			pos += 4
-
-		} else if (ele.Category == 11 || ele.Category == 12) {
-			pos += 8
-			binary.Read(bytes.NewBuffer(b[pos:pos+4]), binary.LittleEndian, &ele.SuoGu)
-			pos += 8
-
-		} else if (ele.Category == 13 || ele.Category == 14) {
-			binary.Read(bytes.NewBuffer(b[pos:pos+4]), binary.LittleEndian, &ele.XingQuanJia)
-			pos += 8
-			binary.Read(bytes.NewBuffer(b[pos:pos+4]), binary.LittleEndian, &ele.FenShu)
-			pos += 8
-		} else {
-			binary.Read(bytes.NewBuffer(b[pos:pos+4]), binary.LittleEndian, &panqianliutong_raw)
-			pos += 4
-			binary.Read(bytes.NewBuffer(b[pos:pos+4]), binary.LittleEndian, &panhouliutong_raw)
-			pos += 4
-			binary.Read(bytes.NewBuffer(b[pos:pos+4]), binary.LittleEndian, &qianzongguben_raw)
-			pos += 4
-			binary.Read(bytes.NewBuffer(b[pos:pos+4]), binary.LittleEndian, &houzongguben_raw)
-			pos += 4
-			ele.PanQianLiuTong = c.getv(panqianliutong_raw)
-			ele.PanHouLiuTong = c.getv(panhouliutong_raw)
-			ele.QianZongGuBen = c.getv(qianzongguben_raw)
-			ele.HouZongGuBen = c.getv(houzongguben_raw)
+		data := bytes.NewBuffer(b[pos : pos+16])
+		switch ele.Category {
+		case 1:
+			binary.Read(data, binary.LittleEndian, &ele.FenHong)
+			binary.Read(data, binary.LittleEndian, &ele.PeiGuJia)
+			binary.Read(data, binary.LittleEndian, &ele.SongZhuanGu)
+			binary.Read(data, binary.LittleEndian, &ele.PeiGu)
+		case 11, 12:
+			data.Next(8)
+			binary.Read(data, binary.LittleEndian, &ele.SuoGu)
+		case 13, 14:
+			binary.Read(data, binary.LittleEndian, &ele.XingQuanJia)
+			data.Next(4)
+			binary.Read(data, binary.LittleEndian, &ele.FenShu)
+		default:
+			var raw [4]uint32 // 盘前流通, 前总股本, 盘后流通, 后总股本
+			binary.Read(data, binary.LittleEndian, &raw)
+			ele.PanQianLiuTong = c.getv(raw[0])
+			ele.QianZongGuBen = c.getv(raw[1])
+			ele.PanHouLiuTong = c.getv(raw[2])
+			ele.HouZongGuBen = c.getv(raw[3])
 		}
+		pos += 16
 		ele.Describe = c.getcategoryname(ele.Category)
 		c.List = append(c.List, ele)
 	}
@@ -0,0 +1,77 @@
+package imsg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+)
+
+// 按 pytdx 的解析格式构造的响应体, 前 9 字节为市场和代码等请求回显
+func xdxrRecord(buf *bytes.Buffer, date uint32, category uint8, data [4]uint32) {
+	buf.WriteByte(MARKET_SH)
+	buf.WriteString("600000")
+	buf.WriteByte(0)
+	binary.Write(buf, binary.LittleEndian, date)
+	buf.WriteByte(category)
+	binary.Write(buf, binary.LittleEndian, data)
+}
+
+func TestTDXXdxrInfoMessage_UnSerialize(t *testing.T) {
+	buf := new(bytes.Buffer)
+	buf.Write([]byte{0x01, 0x00, '6', '0', '0', '0', '0', '0', 0x00})
+	binary.Write(buf, binary.LittleEndian, uint16(4))
+	f := math.Float32bits
+	// 2020-07-23 每10股派现 7.57 元, 送转 3 股
+	xdxrRecord(buf, 20200723, 1, [4]uint32{f(7.57), 0, f(3), 0})
+	// 股本变化, 顺序为 盘前流通 前总股本 盘后流通 后总股本
+	xdxrRecord(buf, 20190101, 5, [4]uint32{f(100), f(200), f(150), f(250)})
+	// 缩股
+	xdxrRecord(buf, 20180101, 11, [4]uint32{0, 0, f(0.5), 0})
+	// 送认购权证
+	xdxrRecord(buf, 20170101, 13, [4]uint32{f(4.5), 0, f(10), 0})
+
+	msg := NewTDXXdxrInfoMessage(TDXXdxrInfoRequest{})
+	if err := msg.UnSerialize(TDXRespHeader{}, buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Num != 4 || len(msg.List) != 4 {
+		t.Fatalf("Num = %d, len(List) = %d, want 4", msg.Num, len(msg.List))
+	}
+
+	div := msg.List[0]
+	if div.Code != "600000" || div.Describe != "除权除息" {
+		t.Errorf("record 0 = %+v", div)
+	}
+	if want := time.Date(2020, 7, 23, 0, 0, 0, 0, ChinaLocation); !div.Date.Equal(want) {
+		t.Errorf("Date = %v, want %v", div.Date, want)
+	}
+	if div.FenHong != 7.57 || div.SongZhuanGu != 3 || div.PeiGu != 0 || div.PeiGuJia != 0 {
+		t.Errorf("dividend = %+v", div)
+	}
+
+	shares := msg.List[1]
+	if shares.PanQianLiuTong != 100 || shares.QianZongGuBen != 200 ||
+		shares.PanHouLiuTong != 150 || shares.HouZongGuBen != 250 {
+		t.Errorf("share change = %+v", shares)
+	}
+	if msg.List[2].SuoGu != 0.5 {
+		t.Errorf("SuoGu = %v, want 0.5", msg.List[2].SuoGu)
+	}
+	if w := msg.List[3]; w.XingQuanJia != 4.5 || w.FenShu != 10 {
+		t.Errorf("warrant = %+v", w)
+	}
+}
+
+func TestTDXXdxrInfoMessage_Truncated(t *testing.T) {
+	buf := new(bytes.Buffer)
+	buf.Write(make([]byte, 9))
+	binary.Write(buf, binary.LittleEndian, uint16(2))
+	xdxrRecord(buf, 20200723, 1, [4]uint32{})
+
+	msg := NewTDXXdxrInfoMessage(TDXXdxrInfoRequest{})
+	if err := msg.UnSerialize(TDXRespHeader{}, buf.Bytes()); err == nil {
+		t.Error("expected error for truncated record")
+	}
+}
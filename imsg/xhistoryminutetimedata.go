@@ -1 +1,57 @@
 package imsg
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+type TDXExHistoryMinuteTimeDataRequest struct {
+	Date   uint32 // yyyymmdd
+	Market uint8
+	Code   [9]byte
+}
+
+func NewTDXExHistoryMinuteTimeDataRequest(date uint32, market uint8, code string) TDXExHistoryMinuteTimeDataRequest {
+	req := TDXExHistoryMinuteTimeDataRequest{Date: date, Market: market}
+	copy(req.Code[:], code)
+	return req
+}
+
+type TDXExHistoryMinuteTimeDataResponse struct {
+	Num  uint16
+	List []ExMinuteTimeElement
+}
+
+type TDXExHistoryMinuteTimeDataMessage struct {
+	TDXReqHeader
+	TDXExHistoryMinuteTimeDataRequest
+	TDXRespHeader
+	TDXExHistoryMinuteTimeDataResponse
+}
+
+func NewTDXExHistoryMinuteTimeDataMessage(req TDXExHistoryMinuteTimeDataRequest) *TDXExHistoryMinuteTimeDataMessage {
+	sub := new(TDXExHistoryMinuteTimeDataMessage)
+	sub.TDXExHistoryMinuteTimeDataRequest = req
+	sub.TDXReqHeader = TDXReqHeader{0x01, SeqID(), 0x01,
+		0x10, 0x10, KMSG_EXHISTORYMINUTETIMEDATA}
+	return sub
+}
+
+func (c *TDXExHistoryMinuteTimeDataMessage) MessageNumber() int32 {
+	return KMSG_EXHISTORYMINUTETIMEDATA
+}
+
+func (c *TDXExHistoryMinuteTimeDataMessage) Serialize() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	err := binary.Write(buf, binary.LittleEndian, c.TDXReqHeader)
+	err = binary.Write(buf, binary.LittleEndian, c.TDXExHistoryMinuteTimeDataRequest)
+	return buf.Bytes(), err
+}
+
+func (c *TDXExHistoryMinuteTimeDataMessage) UnSerialize(header interface{}, b []byte) error {
+	h := header.(TDXRespHeader)
+	c.TDXRespHeader = h
+	num, list, err := unpackExMinutes(b, 20)
+	c.Num, c.List = num, list
+	return err
+}
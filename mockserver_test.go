@@ -0,0 +1,123 @@
+package gotdx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/axgle/mahonia"
+	. "gotdx/imsg"
+	"io"
+	"net"
+	"sync"
+	"testing"
+)
+
+// mockServer 模拟通达信行情服务器, 按请求类型返回预设的响应体.
+// 未设置响应的请求不回复, 响应体为 nil 时断开连接.
+type mockServer struct {
+	ln        net.Listener
+	mu        sync.Mutex
+	handlers  map[uint16]func(req []byte) []byte
+	respTypes map[uint16]uint16
+}
+
+func newMockServer(t *testing.T) *mockServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &mockServer{
+		ln:        ln,
+		handlers:  make(map[uint16]func([]byte) []byte),
+		respTypes: make(map[uint16]uint16),
+	}
+	s.Handle(KMSG_CMD1, func([]byte) []byte { return []byte{0} })
+	s.Handle(KMSG_CMD2, func([]byte) []byte { return []byte{0} })
+	s.Handle(KMSG_EXSETUP, func([]byte) []byte { return []byte{0} })
+	go s.serve()
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *mockServer) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// Handle 设置请求类型 typ 的响应, h 收到请求体(不含请求头)
+func (s *mockServer) Handle(typ uint16, h func(req []byte) []byte) {
+	s.mu.Lock()
+	s.handlers[typ] = h
+	s.mu.Unlock()
+}
+
+// HandleAs 同 Handle, 但以 respType 作为响应类型
+func (s *mockServer) HandleAs(typ uint16, respType uint16, h func(req []byte) []byte) {
+	s.mu.Lock()
+	s.handlers[typ] = h
+	s.respTypes[typ] = respType
+	s.mu.Unlock()
+}
+
+func (s *mockServer) Close() {
+	s.ln.Close()
+}
+
+func (s *mockServer) serve() {
+	for {
+		c, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.serveConn(c)
+	}
+}
+
+// serveConn 每个请求在独立协程中处理, 响应可能乱序返回
+func (s *mockServer) serveConn(c net.Conn) {
+	defer c.Close()
+	var wmu sync.Mutex
+	for {
+		var h TDXReqHeader
+		if err := binary.Read(c, binary.LittleEndian, &h); err != nil {
+			return
+		}
+		req := make([]byte, h.PkgLen1-2)
+		if _, err := io.ReadFull(c, req); err != nil {
+			return
+		}
+		s.mu.Lock()
+		handler, ok := s.handlers[h.Type]
+		respType, override := s.respTypes[h.Type]
+		s.mu.Unlock()
+		if !ok {
+			continue
+		}
+		if !override {
+			respType = h.Type
+		}
+		go func(h TDXReqHeader) {
+			body := handler(req)
+			wmu.Lock()
+			defer wmu.Unlock()
+			if body == nil {
+				c.Close()
+				return
+			}
+			buf := new(bytes.Buffer)
+			binary.Write(buf, binary.LittleEndian, TDXRespHeader{
+				I1:        0x0074cbb1,
+				I2:        0x0c,
+				SeqID:     h.SeqID,
+				Type:      respType,
+				ZipSize:   uint16(len(body)),
+				UnZipSize: uint16(len(body)),
+			})
+			buf.Write(body)
+			c.Write(buf.Bytes())
+		}(h)
+	}
+}
+
+// gbkString 把 UTF-8 字符串转为 GBK 编码
+func gbkString(s string) string {
+	return mahonia.NewEncoder("gbk").ConvertString(s)
+}
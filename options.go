@@ -0,0 +1,147 @@
+package gotdx
+
+import (
+	"context"
+	"gotdx/config"
+	. "gotdx/imsg"
+	"net"
+	"sort"
+	"time"
+)
+
+const (
+	DEFAULT_HQ_ADDR         = "47.116.105.28:7709" // 默认行情服务器
+	DEFAULT_EXHQ_ADDR       = "112.74.214.43:7727" // 默认拓展行情服务器
+	DEFAULT_REQUEST_TIMEOUT = 15 * time.Second     // 默认单个请求超时
+)
+
+// Options TdxHq 连接参数
+type Options struct {
+	Addrs          []string      // 服务器地址列表, 按顺序尝试
+	ConnectTimeout time.Duration // 建立连接超时
+	RequestTimeout time.Duration // 单个请求超时, ctx 带截止时间时以 ctx 为准
+	Dialer         *net.Dialer   // 自定义 Dialer
+	// DialContext 自定义拨号函数, 优先于 Dialer
+	DialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+	// ConnFactory 自定义连接工厂, 优先于 DialContext 和 Dialer
+	ConnFactory func(ctx context.Context, addr string) (net.Conn, error)
+	BackoffMin  time.Duration // 首次重连等待时间
+	BackoffMax  time.Duration // 最长重连等待时间
+	// OnStateChange 连接状态变化回调, 在状态切换的协程中同步调用
+	OnStateChange func(from, to ConnState, addr string)
+}
+
+// Option 设置连接参数
+type Option func(*Options)
+
+func defaultOptions() Options {
+	return Options{
+		ConnectTimeout: time.Duration(CONNECT_TIMEOUT * float64(time.Second)),
+		RequestTimeout: DEFAULT_REQUEST_TIMEOUT,
+		BackoffMin:     RECONNECT_INTERVAL * time.Second,
+		BackoffMax:     RECONNECT_MAX_INTERVAL * time.Second,
+	}
+}
+
+// newOptions 应用 opts, 没有指定服务器时使用 addr
+func newOptions(addr string, opts []Option) Options {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if len(o.Addrs) == 0 {
+		o.Addrs = []string{addr}
+	}
+	return o
+}
+
+// WithAddrs 设置服务器地址列表
+func WithAddrs(addrs ...string) Option {
+	return func(o *Options) {
+		o.Addrs = append(o.Addrs, addrs...)
+	}
+}
+
+// WithConfig 使用 connect.cfg 中 tag 分组下的服务器, 按站点名称排序
+func WithConfig(c *config.TDXConfig, tag string) Option {
+	return func(o *Options) {
+		remoter := c.Remoter(tag)
+		names := make([]string, 0, len(remoter))
+		for name := range remoter {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			o.Addrs = append(o.Addrs, remoter[name])
+		}
+	}
+}
+
+// WithConnectTimeout 设置建立连接超时
+func WithConnectTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.ConnectTimeout = timeout
+	}
+}
+
+// WithRequestTimeout 设置单个请求超时, 0 表示只受 ctx 控制
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.RequestTimeout = timeout
+	}
+}
+
+// WithBackoff 设置重连等待时间, 每次失败后翻倍直到 max, 并加入随机抖动
+func WithBackoff(min, max time.Duration) Option {
+	return func(o *Options) {
+		o.BackoffMin = min
+		o.BackoffMax = max
+	}
+}
+
+// WithStateChange 设置连接状态变化回调
+func WithStateChange(f func(from, to ConnState, addr string)) Option {
+	return func(o *Options) {
+		o.OnStateChange = f
+	}
+}
+
+// WithDialer 设置自定义 Dialer
+func WithDialer(dialer *net.Dialer) Option {
+	return func(o *Options) {
+		o.Dialer = dialer
+	}
+}
+
+// WithDialContext 设置自定义拨号函数
+func WithDialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) Option {
+	return func(o *Options) {
+		o.DialContext = dial
+	}
+}
+
+// WithConnFactory 设置自定义连接工厂, 可用于代理或测试
+func WithConnFactory(factory func(ctx context.Context, addr string) (net.Conn, error)) Option {
+	return func(o *Options) {
+		o.ConnFactory = factory
+	}
+}
+
+func (o *Options) dial(addr string) (net.Conn, error) {
+	ctx := context.Background()
+	if o.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.ConnectTimeout)
+		defer cancel()
+	}
+	switch {
+	case o.ConnFactory != nil:
+		return o.ConnFactory(ctx, addr)
+	case o.DialContext != nil:
+		return o.DialContext(ctx, "tcp", addr)
+	case o.Dialer != nil:
+		return o.Dialer.DialContext(ctx, "tcp", addr)
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, "tcp", addr)
+}
@@ -0,0 +1,57 @@
+package gotdx
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestNewTdxHqWithOptions_Failover(t *testing.T) {
+	srv := newMockServer(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := ln.Addr().String()
+	ln.Close()
+
+	hq, err := NewTdxHqWithOptions(WithAddrs(closed, srv.Addr()), WithConnectTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hq.Addr() != srv.Addr() {
+		t.Errorf("connected to %s, want %s", hq.Addr(), srv.Addr())
+	}
+}
+
+func TestNewTdxHqWithOptions_Error(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := ln.Addr().String()
+	ln.Close()
+
+	if _, err := NewTdxHqWithOptions(WithAddrs(closed)); err == nil {
+		t.Fatal("expected error when no server is reachable")
+	}
+}
+
+func TestNewTdxHqWithOptions_ConnFactory(t *testing.T) {
+	srv := newMockServer(t)
+
+	var dialed string
+	factory := func(ctx context.Context, addr string) (net.Conn, error) {
+		dialed = addr
+		var d net.Dialer
+		return d.DialContext(ctx, "tcp", srv.Addr())
+	}
+	if _, err := NewTdxHqWithOptions(WithAddrs("mirror:7709"), WithConnFactory(factory)); err != nil {
+		t.Fatal(err)
+	}
+	if dialed != "mirror:7709" {
+		t.Errorf("factory dialed %q, want %q", dialed, "mirror:7709")
+	}
+}
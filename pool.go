@@ -0,0 +1,438 @@
+package gotdx
+
+import (
+	"context"
+	"errors"
+	. "gotdx/imsg"
+	"gotdx/logger"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	POOL_EWMA_WEIGHT      = 0.2 // 延迟和错误率的平滑系数
+	POOL_MAX_ERROR_RATE   = 0.5 // 错误率超过该值的主机上的连接会被淘汰
+	POOL_MIN_SAMPLES      = 5   // 计算错误率前至少需要的请求数
+	POOL_MAX_CONSEC_FAILS = 3   // 连续失败次数超过该值的连接会被淘汰
+
+	POOL_INFLIGHT_COST = 20 * time.Millisecond // 每个在途请求额外计入的负载, 使新主机上的连接也能分摊请求
+)
+
+// HostStats 主机健康统计
+type HostStats struct {
+	Addr      string
+	Conns     int           // 当前连接数
+	Requests  int64         // 请求总数
+	Errors    int64         // 失败总数
+	Latency   time.Duration // 平滑后的请求延迟
+	ErrorRate float64       // 平滑后的错误率
+}
+
+type poolHost struct {
+	HostStats
+	consecFails int
+}
+
+// score 主机得分, 越小越好. 没有请求记录的主机得分为 0, 会被优先尝试.
+func (h *poolHost) score() float64 {
+	return float64(h.Latency) * (1 + 10*h.ErrorRate)
+}
+
+func (h *poolHost) record(latency time.Duration, failed bool) {
+	h.Requests++
+	errSample := 0.0
+	if failed {
+		h.Errors++
+		h.consecFails++
+		errSample = 1
+	} else {
+		h.consecFails = 0
+	}
+	if h.Requests == 1 {
+		h.Latency = latency
+	} else {
+		h.Latency = time.Duration(POOL_EWMA_WEIGHT*float64(latency) + (1-POOL_EWMA_WEIGHT)*float64(h.Latency))
+	}
+	h.ErrorRate = POOL_EWMA_WEIGHT*errSample + (1-POOL_EWMA_WEIGHT)*h.ErrorRate
+}
+
+// reset 在主机上成功建立新连接后清除错误率和连续失败次数, 旧的错误不再淘汰新连接
+func (h *poolHost) reset() {
+	h.consecFails = 0
+	h.ErrorRate = 0
+}
+
+func (h *poolHost) unhealthy() bool {
+	return h.consecFails >= POOL_MAX_CONSEC_FAILS ||
+		(h.Requests >= POOL_MIN_SAMPLES && h.ErrorRate > POOL_MAX_ERROR_RATE)
+}
+
+var _ ITdxHq = (*Pool)(nil)
+
+type poolConn struct {
+	hq       *TdxHq
+	host     *poolHost
+	inflight int
+	evicted  bool
+}
+
+// Pool 多服务器连接池, 实现 ITdxHq.
+// 请求分配给负载最小的连接, 见 load. 超时和断线会计入主机错误率,
+// 不健康主机上的连接在请求超时或断线时被淘汰, 并在得分最好的主机上补充.
+type Pool struct {
+	opts  Options
+	hosts []*poolHost
+
+	mu        sync.Mutex
+	conns     []*poolConn
+	replacing int           // 正在补充的连接数
+	changed   chan struct{} // 补充连接完成或连接池关闭时关闭并换新, 用于唤醒等待连接的请求
+	closed    bool
+	inflight  sync.WaitGroup
+}
+
+// NewPool 创建 size 条连接, 轮流分布在 opts 指定的服务器上, 例如
+//  NewPool(20, WithConfig(&cfg, config.HQHOST))
+func NewPool(size int, opts ...Option) (*Pool, error) {
+	if size <= 0 {
+		return nil, ErrParameter
+	}
+	o := newOptions(DEFAULT_HQ_ADDR, opts)
+	p := &Pool{opts: o, changed: make(chan struct{})}
+	for _, addr := range o.Addrs {
+		p.hosts = append(p.hosts, &poolHost{HostStats: HostStats{Addr: addr}})
+	}
+
+	var err error
+	for i := 0; i < size; i++ {
+		var c *poolConn
+		if c, err = p.dial(p.hosts[i%len(p.hosts)]); err != nil {
+			continue
+		}
+		p.conns = append(p.conns, c)
+	}
+	if len(p.conns) == 0 {
+		return nil, err
+	}
+	return p, nil
+}
+
+// dial 优先连接 first, 失败后按得分依次尝试其它主机
+func (p *Pool) dial(first *poolHost) (*poolConn, error) {
+	candidates := []*poolHost{first}
+	p.mu.Lock()
+	others := p.rankedHosts()
+	p.mu.Unlock()
+	for _, h := range others {
+		if h != first {
+			candidates = append(candidates, h)
+		}
+	}
+
+	var err error
+	for _, h := range candidates {
+		opts := p.opts
+		opts.Addrs = []string{h.Addr}
+		hq := newTdxHq(opts)
+		if err = hq.start(); err != nil {
+			// 连接失败按连接超时计入延迟, 避免失败的主机得分反而变好
+			p.mu.Lock()
+			h.record(p.opts.ConnectTimeout, true)
+			p.mu.Unlock()
+			continue
+		}
+		p.mu.Lock()
+		h.Conns++
+		p.mu.Unlock()
+		return &poolConn{hq: hq, host: h}, nil
+	}
+	return nil, err
+}
+
+// rankedHosts 按得分从好到差排列的主机, 调用方持有 p.mu
+func (p *Pool) rankedHosts() []*poolHost {
+	hosts := make([]*poolHost, len(p.hosts))
+	copy(hosts, p.hosts)
+	sort.SliceStable(hosts, func(i, j int) bool {
+		if hosts[i].unhealthy() != hosts[j].unhealthy() {
+			return !hosts[i].unhealthy()
+		}
+		return hosts[i].score() < hosts[j].score()
+	})
+	return hosts
+}
+
+// load 连接负载: 主机得分*(1+在途请求数) 再加上每个在途请求 POOL_INFLIGHT_COST,
+// 没有请求记录的主机得分为 0, 后一项使并发请求仍按在途数分摊
+func (c *poolConn) load() float64 {
+	return c.host.score()*float64(1+c.inflight) + float64(c.inflight)*float64(POOL_INFLIGHT_COST)
+}
+
+// acquire 选择负载最小的连接. 连接都已淘汰而仍在补充时, 在 ctx 结束前等待补充完成.
+func (p *Pool) acquire(ctx context.Context, exclude *poolConn) (*poolConn, error) {
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return nil, ErrClientClosed
+		}
+		var best *poolConn
+		var bestLoad float64
+		for _, c := range p.conns {
+			if c == exclude && len(p.conns) > 1 {
+				continue
+			}
+			load := c.load()
+			if best == nil || load < bestLoad {
+				best, bestLoad = c, load
+			}
+		}
+		if best != nil {
+			best.inflight++
+			p.inflight.Add(1)
+			p.mu.Unlock()
+			return best, nil
+		}
+		if p.replacing == 0 {
+			p.mu.Unlock()
+			return nil, ErrConnReset{Err: errors.New("no connection available")}
+		}
+		changed := p.changed
+		p.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, connError(ctx, ctx.Err())
+		}
+	}
+}
+
+// notify 唤醒等待连接的请求, 调用方持有 p.mu
+func (p *Pool) notify() {
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+func (p *Pool) release(c *poolConn, latency time.Duration, err error) {
+	p.mu.Lock()
+	c.inflight--
+	c.host.record(latency, IsRetryable(err))
+	// 只在本次请求超时或断线时淘汰, 成功的请求不会因主机的历史错误淘汰连接
+	evict := !c.evicted && IsRetryable(err) && c.host.unhealthy()
+	if evict {
+		c.evicted = true
+		for i, pc := range p.conns {
+			if pc == c {
+				p.conns = append(p.conns[:i], p.conns[i+1:]...)
+				break
+			}
+		}
+		c.host.Conns--
+	}
+	closeNow := c.evicted && c.inflight == 0
+	closed := p.closed
+	if evict && !closed {
+		p.replacing++
+	}
+	p.mu.Unlock()
+	p.inflight.Done()
+
+	if closeNow {
+		c.hq.Close()
+	}
+	if evict && !closed {
+		logger.Warnf("evict connection to %s, error rate %.2f\n", c.host.Addr, c.host.ErrorRate)
+		go p.replace()
+	}
+}
+
+// replace 在得分最好的主机上补充一条连接, 调用前 p.replacing 已加一
+func (p *Pool) replace() {
+	p.mu.Lock()
+	ranked := p.rankedHosts()
+	p.mu.Unlock()
+	c, err := p.dial(ranked[0])
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.replacing--
+	p.notify()
+	if err != nil {
+		logger.Errorf("replace pool connection: %v\n", err)
+		return
+	}
+	if p.closed {
+		c.hq.Close()
+		return
+	}
+	c.host.reset()
+	p.conns = append(p.conns, c)
+}
+
+// do 在选出的连接上执行 f, 超时或断线时换一条连接重试一次
+func (p *Pool) do(ctx context.Context, f func(hq *TdxHq) error) error {
+	var last *poolConn
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		var c *poolConn
+		if c, err = p.acquire(ctx, last); err != nil {
+			return err
+		}
+		start := time.Now()
+		err = f(c.hq)
+		p.release(c, time.Since(start), err)
+		if !IsRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+		last = c
+	}
+	return err
+}
+
+// Stats 各主机的健康统计
+func (p *Pool) Stats() []HostStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := make([]HostStats, 0, len(p.hosts))
+	for _, h := range p.hosts {
+		stats = append(stats, h.HostStats)
+	}
+	return stats
+}
+
+// Close 拒绝新的请求, 等待在途请求完成后关闭所有连接
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.notify()
+	p.mu.Unlock()
+
+	p.inflight.Wait()
+
+	p.mu.Lock()
+	conns := p.conns
+	p.conns = nil
+	p.mu.Unlock()
+	for _, c := range conns {
+		c.hq.Close()
+	}
+	return nil
+}
+
+func (p *Pool) SecurityCount(ctx context.Context, req TDXSecurityCountRequest) (rsp TDXSecurityCountResponse, err error) {
+	err = p.do(ctx, func(hq *TdxHq) (e error) {
+		rsp, e = hq.SecurityCount(ctx, req)
+		return
+	})
+	return
+}
+
+func (p *Pool) BlockInfo(ctx context.Context, file string) (rsp TDXBlockInfoResponse, err error) {
+	err = p.do(ctx, func(hq *TdxHq) (e error) {
+		rsp, e = hq.BlockInfo(ctx, file)
+		return
+	})
+	return
+}
+
+func (p *Pool) CompanyInfoCategory(ctx context.Context, req TDXCompanyInfoCategoryRequest) (rsp TDXCompanyInfoCategoryResponse, err error) {
+	err = p.do(ctx, func(hq *TdxHq) (e error) {
+		rsp, e = hq.CompanyInfoCategory(ctx, req)
+		return
+	})
+	return
+}
+
+func (p *Pool) CompanyInfoContent(ctx context.Context, req TDXCompanyInfoContentRequest) (rsp TDXCompanyInfoContentResponse, err error) {
+	err = p.do(ctx, func(hq *TdxHq) (e error) {
+		rsp, e = hq.CompanyInfoContent(ctx, req)
+		return
+	})
+	return
+}
+
+func (p *Pool) FinanceInfo(ctx context.Context, req TDXFinanceInfoRequest) (rsp TDXFinanceInfoResponse, err error) {
+	err = p.do(ctx, func(hq *TdxHq) (e error) {
+		rsp, e = hq.FinanceInfo(ctx, req)
+		return
+	})
+	return
+}
+
+func (p *Pool) HistoryMinuteTimeDate(ctx context.Context, req TDXHistoryMinuteTimeDateRequest) (rsp TDXHistoryMinuteTimeDateResponse, err error) {
+	err = p.do(ctx, func(hq *TdxHq) (e error) {
+		rsp, e = hq.HistoryMinuteTimeDate(ctx, req)
+		return
+	})
+	return
+}
+
+func (p *Pool) HistoryTransactionData(ctx context.Context, req TDXHistoryTransactionDataRequest) (rsp TDXHistoryTransactionDataResponse, err error) {
+	err = p.do(ctx, func(hq *TdxHq) (e error) {
+		rsp, e = hq.HistoryTransactionData(ctx, req)
+		return
+	})
+	return
+}
+
+func (p *Pool) IndexBars(ctx context.Context, req TDXIndexBarsRequest) (rsp TDXIndexBarsResponse, err error) {
+	err = p.do(ctx, func(hq *TdxHq) (e error) {
+		rsp, e = hq.IndexBars(ctx, req)
+		return
+	})
+	return
+}
+
+func (p *Pool) SecurityBars(ctx context.Context, req TDXSecurityBarsRequest) (rsp TDXSecurityBarsResponse, err error) {
+	err = p.do(ctx, func(hq *TdxHq) (e error) {
+		rsp, e = hq.SecurityBars(ctx, req)
+		return
+	})
+	return
+}
+
+func (p *Pool) MinuteTimeData(ctx context.Context, req TDXMinuteTimeDataRequest) (rsp TDXMinuteTimeDataResponse, err error) {
+	err = p.do(ctx, func(hq *TdxHq) (e error) {
+		rsp, e = hq.MinuteTimeData(ctx, req)
+		return
+	})
+	return
+}
+
+func (p *Pool) SecurityList(ctx context.Context, req TDXSecurityListRequest) (rsp TDXSecurityListResponse, err error) {
+	err = p.do(ctx, func(hq *TdxHq) (e error) {
+		rsp, e = hq.SecurityList(ctx, req)
+		return
+	})
+	return
+}
+
+func (p *Pool) SecurityQuotes(ctx context.Context, req TDXSecurityQuotesRequest) (rsp TDXSecurityQuotesResponse, err error) {
+	err = p.do(ctx, func(hq *TdxHq) (e error) {
+		rsp, e = hq.SecurityQuotes(ctx, req)
+		return
+	})
+	return
+}
+
+func (p *Pool) TransactionData(ctx context.Context, req TDXTransactionDataRequest) (rsp TDXTransactionDataResponse, err error) {
+	err = p.do(ctx, func(hq *TdxHq) (e error) {
+		rsp, e = hq.TransactionData(ctx, req)
+		return
+	})
+	return
+}
+
+func (p *Pool) XdxrInfo(ctx context.Context, req TDXXdxrInfoRequest) (rsp TDXXdxrInfoResponse, err error) {
+	err = p.do(ctx, func(hq *TdxHq) (e error) {
+		rsp, e = hq.XdxrInfo(ctx, req)
+		return
+	})
+	return
+}
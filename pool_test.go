@@ -0,0 +1,231 @@
+package gotdx
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	. "gotdx/imsg"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func countHandler(count uint16) func([]byte) []byte {
+	return func([]byte) []byte {
+		b := make([]byte, 2)
+		binary.LittleEndian.PutUint16(b, count)
+		return b
+	}
+}
+
+func TestPool_EvictUnhealthyHost(t *testing.T) {
+	bad := newMockServer(t) // 不响应证券数量请求
+	good := newMockServer(t)
+	good.Handle(KMSG_SECURITYCOUNT, countHandler(1))
+
+	// 唯一的连接建立在 bad 上, 连续失败后应被淘汰并在 good 上补充
+	pool, err := NewPool(1, WithAddrs(bad.Addr(), good.Addr()), WithRequestTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	var rsp TDXSecurityCountResponse
+	for i := 0; i < 20; i++ {
+		if rsp, err = pool.SecurityCount(context.Background(), TDXSecurityCountRequest{Market: MARKET_SH}); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil || rsp.Count != 1 {
+		t.Fatalf("pool did not recover: %v %+v", err, rsp)
+	}
+
+	for _, s := range pool.Stats() {
+		switch s.Addr {
+		case bad.Addr():
+			if s.Errors < POOL_MAX_CONSEC_FAILS || s.Conns != 0 {
+				t.Errorf("bad host not evicted: %+v", s)
+			}
+		case good.Addr():
+			if s.Errors != 0 || s.Conns != 1 {
+				t.Errorf("good host stats: %+v", s)
+			}
+		}
+	}
+}
+
+func TestPool_PreferFastHost(t *testing.T) {
+	slow := newMockServer(t)
+	slow.Handle(KMSG_SECURITYCOUNT, func(req []byte) []byte {
+		time.Sleep(20 * time.Millisecond)
+		return []byte{2, 0}
+	})
+	fast := newMockServer(t)
+	fast.Handle(KMSG_SECURITYCOUNT, countHandler(1))
+
+	pool, err := NewPool(2, WithAddrs(slow.Addr(), fast.Addr()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	fastCount := 0
+	for i := 0; i < 20; i++ {
+		rsp, err := pool.SecurityCount(context.Background(), TDXSecurityCountRequest{Market: MARKET_SH})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rsp.Count == 1 {
+			fastCount++
+		}
+	}
+	if fastCount < 18 {
+		t.Errorf("only %d of 20 requests went to the fast host", fastCount)
+	}
+}
+
+func TestPool_CloseDrainsInflight(t *testing.T) {
+	srv := newMockServer(t)
+	srv.Handle(KMSG_SECURITYCOUNT, func(req []byte) []byte {
+		time.Sleep(100 * time.Millisecond)
+		return []byte{7, 0}
+	})
+	pool, err := NewPool(2, WithAddrs(srv.Addr()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		rsp, err := pool.SecurityCount(context.Background(), TDXSecurityCountRequest{Market: MARKET_SH})
+		if err != nil || rsp.Count != 7 {
+			t.Errorf("in-flight request: %v %+v", err, rsp)
+		}
+	}()
+	time.Sleep(20 * time.Millisecond)
+	pool.Close()
+	wg.Wait()
+
+	if _, err := pool.SecurityCount(context.Background(), TDXSecurityCountRequest{}); err != ErrClientClosed {
+		t.Errorf("err after Close = %v, want ErrClientClosed", err)
+	}
+}
+
+func TestPool_FailedDialRanksLast(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dead := ln.Addr().String()
+	ln.Close()
+	good := newMockServer(t)
+
+	pool, err := NewPool(1, WithAddrs(dead, good.Addr()), WithConnectTimeout(100*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	pool.mu.Lock()
+	ranked := pool.rankedHosts()
+	pool.mu.Unlock()
+	if ranked[0].Addr != good.Addr() {
+		t.Errorf("host that failed to dial ranked first: %+v", ranked[0].HostStats)
+	}
+}
+
+func TestPool_SpreadBurstOnFreshHost(t *testing.T) {
+	srv := newMockServer(t)
+	pool, err := NewPool(4, WithAddrs(srv.Addr()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	// 没有请求记录时主机得分为 0, 并发请求仍应分配到不同连接
+	seen := make(map[*poolConn]bool)
+	var conns []*poolConn
+	for i := 0; i < 4; i++ {
+		c, err := pool.acquire(context.Background(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[c] = true
+		conns = append(conns, c)
+	}
+	for _, c := range conns {
+		pool.release(c, time.Millisecond, nil)
+	}
+	if len(seen) != 4 {
+		t.Errorf("4 concurrent requests went to %d connections", len(seen))
+	}
+}
+
+func TestPool_SuccessDoesNotEvict(t *testing.T) {
+	srv := newMockServer(t)
+	srv.Handle(KMSG_SECURITYCOUNT, countHandler(1))
+	pool, err := NewPool(1, WithAddrs(srv.Addr()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	// 主机的历史错误率仍很高, 但成功的请求不应淘汰唯一的连接
+	pool.mu.Lock()
+	conn := pool.conns[0]
+	conn.host.Requests, conn.host.ErrorRate = 10, 0.9
+	pool.mu.Unlock()
+	for i := 0; i < 3; i++ {
+		if _, err := pool.SecurityCount(context.Background(), TDXSecurityCountRequest{Market: MARKET_SH}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if len(pool.conns) != 1 || pool.conns[0] != conn || pool.replacing != 0 {
+		t.Errorf("connection evicted after successful requests")
+	}
+}
+
+func TestPool_WaitForReplacement(t *testing.T) {
+	srv := newMockServer(t)
+	srv.Handle(KMSG_SECURITYCOUNT, countHandler(1))
+	pool, err := NewPool(1, WithAddrs(srv.Addr()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	// 模拟唯一的连接已被淘汰, 补充连接尚未完成
+	pool.mu.Lock()
+	old := pool.conns[0]
+	pool.conns = nil
+	old.host.Conns--
+	old.host.ErrorRate = 0.9
+	pool.replacing++
+	pool.mu.Unlock()
+	old.hq.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var timeout ErrTimeout
+	if _, err := pool.SecurityCount(ctx, TDXSecurityCountRequest{Market: MARKET_SH}); !errors.As(err, &timeout) {
+		t.Fatalf("err = %v, want ErrTimeout while waiting", err)
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		pool.replace()
+	}()
+	rsp, err := pool.SecurityCount(context.Background(), TDXSecurityCountRequest{Market: MARKET_SH})
+	if err != nil || rsp.Count != 1 {
+		t.Fatalf("request did not wait for replacement: %v", err)
+	}
+	if s := pool.Stats()[0]; s.ErrorRate != 0 || s.Conns != 1 {
+		t.Errorf("host stats after replacement: %+v", s)
+	}
+}
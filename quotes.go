@@ -0,0 +1,72 @@
+package gotdx
+
+import (
+	"context"
+	. "gotdx/imsg"
+	"sync"
+)
+
+const QUOTES_BATCH_CONCURRENCY = 8 // 批量查询行情的并发请求数
+
+// QuotesFor 查询任意数量证券的行情, symbols 的格式见 ParseSymbol, 结果以传入的 symbol 为键.
+// 每个请求最多 MAX_SECURITY_QUOTES_COUNT 只, 请求并发发出, 使用 Pool 时分散到多个连接.
+// 任一请求失败时取消其余请求并返回该错误, 服务器未返回的证券不出现在结果中.
+func QuotesFor(ctx context.Context, hq ITdxHq, symbols []string) (map[string]SecurityQuotesElement, error) {
+	keys := make(map[SecurityKey][]string, len(symbols))
+	var list []ReqSecurityQuotesElement
+	for _, symbol := range symbols {
+		market, code, err := ParseSymbol(symbol)
+		if err != nil {
+			return nil, err
+		}
+		key := SecurityKey{Market: market, Code: code}
+		if _, ok := keys[key]; !ok {
+			ele := ReqSecurityQuotesElement{Market: market}
+			copy(ele.Code[:], code)
+			list = append(list, ele)
+		}
+		keys[key] = append(keys[key], symbol)
+	}
+
+	var mu sync.Mutex
+	result := make(map[string]SecurityQuotesElement, len(symbols))
+	batches := (len(list) + MAX_SECURITY_QUOTES_COUNT - 1) / MAX_SECURITY_QUOTES_COUNT
+	err := fanOut(ctx, batches, QUOTES_BATCH_CONCURRENCY, func(ctx context.Context, i int) error {
+		start, end := i*MAX_SECURITY_QUOTES_COUNT, (i+1)*MAX_SECURITY_QUOTES_COUNT
+		if end > len(list) {
+			end = len(list)
+		}
+		rsp, err := hq.SecurityQuotes(ctx, TDXSecurityQuotesRequest{List: list[start:end]})
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, q := range rsp.QuotesList {
+			for _, symbol := range keys[SecurityKey{Market: q.Market, Code: q.Code}] {
+				result[symbol] = q
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// QuotesForAll 查询 u 中类别为 types 的全部证券的行情, types 为空时查询全部证券,
+// 结果以 "sh600000" 形式的代码为键
+func QuotesForAll(ctx context.Context, hq ITdxHq, u *SecurityUniverse, types ...SecurityType) (map[string]SecurityQuotesElement, error) {
+	want := make(map[SecurityType]bool, len(types))
+	for _, t := range types {
+		want[t] = true
+	}
+	var symbols []string
+	for key, s := range u.Securities {
+		if len(types) == 0 || want[s.Type] {
+			symbols = append(symbols, Symbol(key.Market, key.Code))
+		}
+	}
+	return QuotesFor(ctx, hq, symbols)
+}
@@ -0,0 +1,100 @@
+package gotdx
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	. "gotdx/imsg"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestParseSymbol(t *testing.T) {
+	if market, code, err := ParseSymbol("600000.SH"); err != nil || market != MARKET_SH || code != "600000" {
+		t.Errorf("ParseSymbol = %d, %q, %v", market, code, err)
+	}
+	for _, symbol := range []string{"", "hk000700", "sh60000x"} {
+		if _, _, err := ParseSymbol(symbol); !errors.Is(err, ErrParameter) {
+			t.Errorf("ParseSymbol(%q) err = %v, want ErrParameter", symbol, err)
+		}
+	}
+}
+
+// quotesEcho 返回请求中每只证券的行情, 现价为代码后三位 / 100 元
+type quotesEcho struct {
+	mu       sync.Mutex
+	maxBatch int
+	reqs     int
+}
+
+func (q *quotesEcho) handle(req []byte) []byte {
+	n := int(binary.LittleEndian.Uint16(req[8:10]))
+	q.mu.Lock()
+	q.reqs++
+	if n > q.maxBatch {
+		q.maxBatch = n
+	}
+	q.mu.Unlock()
+
+	buf := new(bytes.Buffer)
+	buf.Write([]byte{0, 0})
+	binary.Write(buf, binary.LittleEndian, uint16(n))
+	for i := 0; i < n; i++ {
+		ele := req[10+i*7 : 17+i*7]
+		buf.Write(ele)
+		buf.Write([]byte{0, 0})
+		price, _ := strconv.Atoi(string(ele[4:7]))
+		putPrice(buf, price)
+		for j := 0; j < 8; j++ {
+			putPrice(buf, 0)
+		}
+		buf.Write(make([]byte, 4))
+		for j := 0; j < 4+20; j++ {
+			putPrice(buf, 0)
+		}
+		buf.Write(make([]byte, 2))
+		for j := 0; j < 4; j++ {
+			putPrice(buf, 0)
+		}
+		buf.Write(make([]byte, 4))
+	}
+	return buf.Bytes()
+}
+
+func TestQuotesFor(t *testing.T) {
+	hq, srv := newMockHq(t)
+	echo := &quotesEcho{}
+	srv.Handle(KMSG_SECURITYQUOTES, echo.handle)
+
+	var symbols []string
+	for i := 0; i < 200; i++ {
+		symbols = append(symbols, fmt.Sprintf("sh600%03d", i), fmt.Sprintf("%06d.SZ", i))
+	}
+	symbols = append(symbols, "600007")
+
+	quotes, err := QuotesFor(context.Background(), hq, symbols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quotes) != len(symbols) {
+		t.Fatalf("got %d quotes, want %d", len(quotes), len(symbols))
+	}
+	if echo.maxBatch > MAX_SECURITY_QUOTES_COUNT || echo.reqs != 5 {
+		t.Errorf("%d requests with max batch %d", echo.reqs, echo.maxBatch)
+	}
+	for _, symbol := range []string{"sh600123", "000123.SZ"} {
+		if q := quotes[symbol]; q.Price != 1.23 {
+			t.Errorf("%s price = %v, want 1.23", symbol, q.Price)
+		}
+	}
+	if q := quotes["600007"]; q.Market != MARKET_SH || q.Code != "600007" {
+		t.Errorf("600007 = %+v", q)
+	}
+
+	if _, err := QuotesFor(context.Background(), hq, []string{"sh600000", "xx"}); !errors.Is(err, ErrParameter) {
+		t.Errorf("err = %v, want ErrParameter", err)
+	}
+}
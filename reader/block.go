@@ -0,0 +1,169 @@
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"github.com/axgle/mahonia"
+	. "gotdx/imsg"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Block 板块
+type Block struct {
+	Name  string
+	Code  string   // 板块指数代码, 没有时为空
+	Type  string   // 板块类别, 如 GN 概念、FG 风格、ZS 指数, 或 tdxzs.cfg 中的类别编号
+	Codes []string // 成员代码
+}
+
+// BlockMembers 板块名称 → 成员代码
+type BlockMembers map[string][]string
+
+// MembersOf 把板块列表转为 BlockMembers, 同名板块的成员合并
+func MembersOf(blocks []Block) BlockMembers {
+	m := make(BlockMembers, len(blocks))
+	for _, b := range blocks {
+		m[b.Name] = append(m[b.Name], b.Codes...)
+	}
+	return m
+}
+
+// ReadBlockFile 读取 T0002/hq_cache 下的 block.dat、block_gn.dat、block_fg.dat 或 block_zs.dat,
+// 格式与 BlockInfo 下载的文件相同. Type 为文件中的类别编号.
+func ReadBlockFile(path string) ([]Block, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rsp, err := DecodeBlockFile(b)
+	if err != nil {
+		return nil, err
+	}
+	blocks := make([]Block, 0, len(rsp.Block))
+	for _, bi := range rsp.Block {
+		blocks = append(blocks, Block{Name: bi.Blockname, Type: fmt.Sprint(bi.Blocktype), Codes: bi.Codelist})
+	}
+	return blocks, nil
+}
+
+// ReadTdxzsCfgFile 读取 tdxzs.cfg 板块指数列表, 每行为 名称|代码|类别|... , 不含成员
+func ReadTdxzsCfgFile(path string) ([]Block, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadTdxzsCfg(f)
+}
+
+func ReadTdxzsCfg(r io.Reader) ([]Block, error) {
+	var blocks []Block
+	err := scanGBKLines(r, func(n int, line string) error {
+		fields := strings.Split(line, "|")
+		if len(fields) < 3 {
+			return fmt.Errorf("tdxzs.cfg line %d: %d fields", n, len(fields))
+		}
+		blocks = append(blocks, Block{Name: fields[0], Code: fields[1], Type: fields[2]})
+		return nil
+	})
+	return blocks, err
+}
+
+// ReadInfoharborBlockFile 读取 infoharbor_block.dat, 见 ReadInfoharborBlock
+func ReadInfoharborBlockFile(path string) ([]Block, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadInfoharborBlock(f)
+}
+
+// ReadInfoharborBlock 读取 infoharbor_block.dat. 板块以 "#类别_名称,成员数,板块代码,..." 开头,
+// 其后各行为逗号分隔的 "市场#代码" 成员, 直到下一个板块.
+func ReadInfoharborBlock(r io.Reader) ([]Block, error) {
+	var blocks []Block
+	err := scanGBKLines(r, func(n int, line string) error {
+		if strings.HasPrefix(line, "#") {
+			fields := strings.Split(line[1:], ",")
+			b := Block{Name: fields[0]}
+			if i := strings.Index(b.Name, "_"); i >= 0 {
+				b.Type, b.Name = b.Name[:i], b.Name[i+1:]
+			}
+			if len(fields) > 2 {
+				b.Code = fields[2]
+			}
+			blocks = append(blocks, b)
+			return nil
+		}
+		if len(blocks) == 0 {
+			return fmt.Errorf("infoharbor_block.dat line %d: members before any block", n)
+		}
+		b := &blocks[len(blocks)-1]
+		for _, member := range strings.Split(line, ",") {
+			if member = strings.TrimSpace(member); member == "" {
+				continue
+			}
+			if i := strings.Index(member, "#"); i >= 0 {
+				member = member[i+1:]
+			}
+			b.Codes = append(b.Codes, member)
+		}
+		return nil
+	})
+	return blocks, err
+}
+
+// ReadBlkFile 读取 T0002/blocknew 下的自定义板块 .blk 文件, 每行为市场 1 位加代码 6 位,
+// 板块名称取文件名
+func ReadBlkFile(path string) (Block, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Block{}, err
+	}
+	defer f.Close()
+	codes, err := ReadBlk(f)
+	if err != nil {
+		return Block{}, err
+	}
+	name := filepath.Base(path)
+	return Block{Name: strings.TrimSuffix(name, filepath.Ext(name)), Codes: codes}, nil
+}
+
+func ReadBlk(r io.Reader) ([]string, error) {
+	var codes []string
+	err := scanGBKLines(r, func(n int, line string) error {
+		switch len(line) {
+		case 7:
+			codes = append(codes, line[1:])
+		case 6:
+			codes = append(codes, line)
+		default:
+			return fmt.Errorf("blk line %d: invalid code %q", n, line)
+		}
+		return nil
+	})
+	return codes, err
+}
+
+// scanGBKLines 逐行读取 GBK 文本, 跳过空行, n 从 1 开始
+func scanGBKLines(r io.Reader, fn func(n int, line string) error) error {
+	dec := mahonia.NewDecoder("gbk")
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(string(bytes.TrimRight(sc.Bytes(), "\x00")))
+		if line == "" {
+			continue
+		}
+		if err := fn(n, dec.ConvertString(line)); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
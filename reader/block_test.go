@@ -0,0 +1,69 @@
+package reader
+
+import (
+	"github.com/axgle/mahonia"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func gbk(s string) *strings.Reader {
+	return strings.NewReader(mahonia.NewEncoder("gbk").ConvertString(s))
+}
+
+func TestReadTdxzsCfg(t *testing.T) {
+	blocks, err := ReadTdxzsCfg(gbk("煤炭|880301|2|1|0|T01\r\n银行|880471|2|1|0|T1001\r\n\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Block{{Name: "煤炭", Code: "880301", Type: "2"}, {Name: "银行", Code: "880471", Type: "2"}}
+	if !reflect.DeepEqual(blocks, want) {
+		t.Errorf("got %+v", blocks)
+	}
+	if _, err := ReadTdxzsCfg(gbk("煤炭|880301\r\n")); err == nil {
+		t.Error("short line: want error")
+	}
+}
+
+func TestReadInfoharborBlock(t *testing.T) {
+	data := "#GN_人工智能,3,880001,20200101,20200102,,\r\n" +
+		"0#000001,1#600000,\r\n" +
+		"1#600519\r\n" +
+		"#FG_低价股,1,880002,20200101,20200102,,\r\n" +
+		"0#000002\r\n"
+	blocks, err := ReadInfoharborBlock(gbk(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Block{
+		{Name: "人工智能", Code: "880001", Type: "GN", Codes: []string{"000001", "600000", "600519"}},
+		{Name: "低价股", Code: "880002", Type: "FG", Codes: []string{"000002"}},
+	}
+	if !reflect.DeepEqual(blocks, want) {
+		t.Errorf("got %+v", blocks)
+	}
+	members := MembersOf(blocks)
+	if len(members["人工智能"]) != 3 {
+		t.Errorf("members = %v", members)
+	}
+	if _, err := ReadInfoharborBlock(gbk("0#000001\r\n")); err == nil {
+		t.Error("members before block: want error")
+	}
+}
+
+func TestReadBlkFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ZXG.blk")
+	ioutil.WriteFile(path, []byte("\r\n1600000\r\n0000001\r\n"), 0644)
+	b, err := ReadBlkFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Name != "ZXG" || !reflect.DeepEqual(b.Codes, []string{"600000", "000001"}) {
+		t.Errorf("got %+v", b)
+	}
+	if _, err := ReadBlk(strings.NewReader("16000\r\n")); err == nil {
+		t.Error("invalid code: want error")
+	}
+}
@@ -0,0 +1,79 @@
+package reader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	. "gotdx/imsg"
+	"io"
+	"path/filepath"
+	"time"
+)
+
+// dayRecord .day 文件记录, 价格按 PriceScale 放大为整数
+type dayRecord struct {
+	Date     uint32 // yyyymmdd
+	Open     uint32
+	High     uint32
+	Low      uint32
+	Close    uint32
+	Amount   float32 // 成交额(元)
+	Vol      uint32  // 成交量, 股票为股
+	Reserved uint32
+}
+
+// DayFilePath 日线文件路径, 例如 vipdoc/sh/lday/sh600000.day
+func DayFilePath(vipdoc string, market uint8, code string) string {
+	dir := marketDir(market)
+	return filepath.Join(vipdoc, dir, "lday", dir+code+".day")
+}
+
+// ReadDayFile 读取日线文件, 市场和代码由文件名确定, 成交量单位见 ReadDay
+func ReadDayFile(path string) ([]SecurityBarsElement, error) {
+	return readBarFile(path, dayCodec)
+}
+
+// ReadDay 读取日线记录, 价格除以 scale. K线时间为当日 15:00, 与网络接口一致.
+// Vol 为文件中的原始成交量, 股票以股为单位, 而网络接口 SecurityBars 的 Vol 以手为单位,
+// 与网络数据比较时需除以 100.
+func ReadDay(r io.Reader, scale float64) ([]SecurityBarsElement, error) {
+	return readBars(r, dayCodec(scale))
+}
+
+// WriteDayFile 写日线文件, 市场和代码由文件名确定, 文件已存在时覆盖, 成交量单位见 ReadDay
+func WriteDayFile(path string, bars []SecurityBarsElement) error {
+	return writeBarFile(path, bars, dayCodec)
+}
+
+// WriteDay 写日线记录, 价格乘以 scale 后取整. Vol 原样写入, 股票应以股为单位,
+// 写入网络接口取得的K线时需先乘以 100, 见 ReadDay.
+func WriteDay(w io.Writer, bars []SecurityBarsElement, scale float64) error {
+	return writeBars(w, bars, dayCodec(scale))
+}
+
+func dayCodec(scale float64) recordCodec {
+	return recordCodec{
+		decode: func(b []byte) (SecurityBarsElement, error) {
+			var rec dayRecord
+			binary.Read(bytes.NewReader(b), binary.LittleEndian, &rec)
+			t := getdate(rec.Date)
+			if t.IsZero() {
+				return SecurityBarsElement{}, fmt.Errorf("invalid date %d", rec.Date)
+			}
+			return newBar(t.Add(15*time.Hour),
+				float64(rec.Open)/scale, float64(rec.High)/scale, float64(rec.Low)/scale, float64(rec.Close)/scale,
+				float64(rec.Vol), float64(rec.Amount)), nil
+		},
+		encode: func(bar SecurityBarsElement) interface{} {
+			return dayRecord{
+				Date:   uint32(bar.Year*10000 + bar.Month*100 + bar.Day),
+				Open:   scaled(bar.Open, scale),
+				High:   scaled(bar.High, scale),
+				Low:    scaled(bar.Low, scale),
+				Close:  scaled(bar.Close, scale),
+				Amount: float32(bar.Amount),
+				Vol:    uint32(bar.Vol),
+			}
+		},
+	}
+}
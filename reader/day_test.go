@@ -0,0 +1,103 @@
+package reader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	. "gotdx/imsg"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPriceScale(t *testing.T) {
+	tests := []struct {
+		market uint8
+		code   string
+		want   float64
+	}{
+		{MARKET_SH, "600000", 100},
+		{MARKET_SH, "000001", 100},
+		{MARKET_SZ, "399001", 100},
+		{MARKET_SH, "900901", 1000},
+		{MARKET_SZ, "200002", 100},
+		{MARKET_SH, "510300", 1000},
+		{MARKET_SZ, "128136", 1000},
+	}
+	for _, tt := range tests {
+		if got := PriceScale(tt.market, tt.code); got != tt.want {
+			t.Errorf("PriceScale(%d, %s) = %v, want %v", tt.market, tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestReadDay(t *testing.T) {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, dayRecord{20210104, 1050, 1090, 1040, 1080, 1.08e8, 10000000, 0})
+	binary.Write(buf, binary.LittleEndian, dayRecord{20210105, 1080, 1085, 1055, 1060, 5.3e7, 5000000, 0})
+	if buf.Len() != 2*RECORD_SIZE {
+		t.Fatalf("record size = %d, want %d", buf.Len()/2, RECORD_SIZE)
+	}
+
+	bars, err := ReadDay(bytes.NewReader(buf.Bytes()), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bars) != 2 {
+		t.Fatalf("got %d bars, want 2", len(bars))
+	}
+	b := bars[0]
+	if b.Open != 10.5 || b.High != 10.9 || b.Low != 10.4 || b.Close != 10.8 || b.Vol != 1e7 || b.Amount != 1.08e8 {
+		t.Errorf("bar 0 = %+v", b)
+	}
+	if want := time.Date(2021, 1, 5, 15, 0, 0, 0, ChinaLocation); !bars[1].Time().Equal(want) || bars[1].DateTime != "2021-01-05 15:00:00" {
+		t.Errorf("bar 1 at %v (%s), want %v", bars[1].Time(), bars[1].DateTime, want)
+	}
+
+	// 截断的记录
+	if _, err := ReadDay(bytes.NewReader(buf.Bytes()[:40]), 100); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("truncated: err = %v", err)
+	}
+	// 无效日期
+	bad := new(bytes.Buffer)
+	binary.Write(bad, binary.LittleEndian, dayRecord{Date: 20211341})
+	if _, err := ReadDay(bad, 100); err == nil {
+		t.Error("invalid date should fail")
+	}
+}
+
+func TestWriteDayFile(t *testing.T) {
+	vipdoc := t.TempDir()
+	path := DayFilePath(vipdoc, MARKET_SH, "510300")
+	if want := filepath.Join(vipdoc, "sh", "lday", "sh510300.day"); path != want {
+		t.Fatalf("DayFilePath = %s, want %s", path, want)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	in := []SecurityBarsElement{
+		newBar(time.Date(2021, 1, 4, 15, 0, 0, 0, ChinaLocation), 5.123, 5.2, 5.1, 5.187, 300000, 1.5e6),
+		newBar(time.Date(2021, 1, 5, 15, 0, 0, 0, ChinaLocation), 5.187, 5.25, 5.15, 5.2, 200000, 1.04e6),
+	}
+	if err := WriteDayFile(path, in); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Size() != int64(len(in)*RECORD_SIZE) {
+		t.Fatalf("stat = %v, %v", fi, err)
+	}
+	out, err := ReadDayFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != len(in) {
+		t.Fatalf("got %d bars, want %d", len(out), len(in))
+	}
+	for i := range in {
+		if out[i] != in[i] {
+			t.Errorf("bar %d = %+v, want %+v", i, out[i], in[i])
+		}
+	}
+}
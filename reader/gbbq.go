@@ -0,0 +1,149 @@
+package reader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	. "gotdx/imsg"
+	"gotdx/security"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+const (
+	GBBQ_KEY_SIZE    = 0x1048 // 密钥表字节数: 18 个轮密钥和 4 个 256 项的 S 盒
+	GBBQ_RECORD_SIZE = 29     // 每条记录的字节数, 前 24 字节加密
+)
+
+// ReadGbbqFile 读取 T0002/hq_cache/gbbq 文件, 见 ReadGbbq
+func ReadGbbqFile(path string, key []byte) ([]XdxrElement, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadGbbq(f, key)
+}
+
+// ReadGbbq 解密 gbbq 文件, 得到与 XdxrInfo 相同的除权除息记录.
+// 文件以 Blowfish 方式逐 8 字节加密, key 为 GBBQ_KEY_SIZE 字节的密钥表(轮密钥在前, 小端),
+// 本包不附带密钥表, 需由调用方提供, 例如取自 pytdx 的 gbbq_reader.
+func ReadGbbq(r io.Reader, key []byte) ([]XdxrElement, error) {
+	if len(key) != GBBQ_KEY_SIZE {
+		return nil, fmt.Errorf("gbbq key has %d bytes, want %d", len(key), GBBQ_KEY_SIZE)
+	}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < 4 {
+		return nil, fmt.Errorf("gbbq: %w", io.ErrUnexpectedEOF)
+	}
+	count := int(binary.LittleEndian.Uint32(b))
+	b = b[4:]
+	if len(b) < count*GBBQ_RECORD_SIZE {
+		return nil, fmt.Errorf("gbbq: %d records declared, %d present: %w", count, len(b)/GBBQ_RECORD_SIZE, io.ErrUnexpectedEOF)
+	}
+
+	c := newGbbqCipher(key)
+	list := make([]XdxrElement, 0, count)
+	clear := make([]byte, GBBQ_RECORD_SIZE)
+	for i := 0; i < count; i++ {
+		rec := b[i*GBBQ_RECORD_SIZE : (i+1)*GBBQ_RECORD_SIZE]
+		for j := 0; j < 24; j += 8 {
+			c.decrypt(clear[j:j+8], rec[j:j+8])
+		}
+		copy(clear[24:], rec[24:])
+		ele, err := decodeGbbqRecord(clear)
+		if err != nil {
+			return nil, fmt.Errorf("gbbq record %d: %w", i, err)
+		}
+		list = append(list, ele)
+	}
+	return list, nil
+}
+
+// XdxrByCode 按证券分组除权除息记录, 组内保持原有顺序
+func XdxrByCode(list []XdxrElement) map[security.SecurityKey][]XdxrElement {
+	m := make(map[security.SecurityKey][]XdxrElement)
+	for _, ele := range list {
+		key := security.SecurityKey{Market: ele.Market, Code: ele.Code}
+		m[key] = append(m[key], ele)
+	}
+	return m
+}
+
+// decodeGbbqRecord 解析解密后的记录: 市场1 代码7 日期4 类别1 数据16, 数据含义与 XdxrInfo 相同,
+// 股本类数据直接以万股为单位的浮点数保存
+func decodeGbbqRecord(b []byte) (XdxrElement, error) {
+	var rec struct {
+		Market   uint8
+		Code     [7]byte
+		Date     uint32
+		Category uint8
+		Data     [4]float32
+	}
+	binary.Read(bytes.NewReader(b), binary.LittleEndian, &rec)
+	ele := XdxrElement{
+		Market:   rec.Market,
+		Code:     string(bytes.TrimRight(rec.Code[:], "\x00")),
+		Date:     getdate(rec.Date),
+		Category: rec.Category,
+		Describe: XDXR_CATEGORY_MAPPING[rec.Category],
+	}
+	if ele.Date.IsZero() {
+		return ele, fmt.Errorf("invalid date %d", rec.Date)
+	}
+	ele.Year, ele.Month, ele.Day = ele.Date.Year(), int(ele.Date.Month()), ele.Date.Day()
+
+	d := rec.Data
+	switch ele.Category {
+	case 1:
+		ele.FenHong, ele.PeiGuJia, ele.SongZhuanGu, ele.PeiGu = d[0], d[1], d[2], d[3]
+	case 11, 12:
+		ele.SuoGu = d[2]
+	case 13, 14:
+		ele.XingQuanJia, ele.FenShu = d[0], d[2]
+	default:
+		ele.PanQianLiuTong = float64(d[0])
+		ele.QianZongGuBen = float64(d[1])
+		ele.PanHouLiuTong = float64(d[2])
+		ele.HouZongGuBen = float64(d[3])
+	}
+	return ele, nil
+}
+
+// gbbqCipher Blowfish 解密, 轮密钥和 S 盒直接取自密钥表
+type gbbqCipher struct {
+	p [18]uint32
+	s [4][256]uint32
+}
+
+func newGbbqCipher(key []byte) *gbbqCipher {
+	c := new(gbbqCipher)
+	for i := range c.p {
+		c.p[i] = binary.LittleEndian.Uint32(key[i*4:])
+	}
+	for i := range c.s {
+		for j := range c.s[i] {
+			c.s[i][j] = binary.LittleEndian.Uint32(key[72+(i*256+j)*4:])
+		}
+	}
+	return c
+}
+
+func (c *gbbqCipher) f(x uint32) uint32 {
+	return ((c.s[0][x>>24] + c.s[1][x>>16&0xff]) ^ c.s[2][x>>8&0xff]) + c.s[3][x&0xff]
+}
+
+// decrypt 解密 8 字节, 两个 32 位字均为小端
+func (c *gbbqCipher) decrypt(dst, src []byte) {
+	l := binary.LittleEndian.Uint32(src) ^ c.p[17]
+	r := binary.LittleEndian.Uint32(src[4:])
+	for i := 16; i > 0; i-- {
+		l, r = r^c.f(l)^c.p[i], l
+	}
+	binary.LittleEndian.PutUint32(dst, r^c.p[0])
+	binary.LittleEndian.PutUint32(dst[4:], l)
+}
@@ -0,0 +1,102 @@
+package reader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	. "gotdx/imsg"
+	"gotdx/security"
+	"io"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// encrypt decrypt 的逆运算, 用于构造测试文件
+func (c *gbbqCipher) encrypt(dst, src []byte) {
+	r := binary.LittleEndian.Uint32(src) ^ c.p[0]
+	l := binary.LittleEndian.Uint32(src[4:])
+	for i := 1; i <= 16; i++ {
+		l, r = r, l^c.f(r)^c.p[i]
+	}
+	binary.LittleEndian.PutUint32(dst, l^c.p[17])
+	binary.LittleEndian.PutUint32(dst[4:], r)
+}
+
+type gbbqTestRecord struct {
+	Market   uint8
+	Code     [7]byte
+	Date     uint32
+	Category uint8
+	Data     [4]float32
+}
+
+// gbbqFile 用 key 加密 records, 生成 gbbq 文件内容
+func gbbqFile(key []byte, records []gbbqTestRecord) []byte {
+	c := newGbbqCipher(key)
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, uint32(len(records)))
+	for _, rec := range records {
+		clear := new(bytes.Buffer)
+		binary.Write(clear, binary.LittleEndian, rec)
+		b := clear.Bytes()
+		for j := 0; j < 24; j += 8 {
+			c.encrypt(b[j:j+8], b[j:j+8])
+		}
+		buf.Write(b)
+	}
+	return buf.Bytes()
+}
+
+func TestReadGbbq(t *testing.T) {
+	key := make([]byte, GBBQ_KEY_SIZE)
+	rand.New(rand.NewSource(1)).Read(key)
+
+	code := func(s string) (b [7]byte) {
+		copy(b[:], s)
+		return
+	}
+	records := []gbbqTestRecord{
+		{MARKET_SH, code("600000"), 20200723, 1, [4]float32{1.5, 0, 0, 0}},
+		{MARKET_SZ, code("000001"), 20190626, 1, [4]float32{1.45, 0, 0, 0}},
+		{MARKET_SH, code("600000"), 20191115, 5, [4]float32{2810376.25, 2810376.25, 2935208, 2935208}},
+		{MARKET_SZ, code("000002"), 20100101, 11, [4]float32{0, 0, 0.5, 0}},
+	}
+	data := gbbqFile(key, records)
+
+	list, err := ReadGbbq(bytes.NewReader(data), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != len(records) {
+		t.Fatalf("got %d records, want %d", len(list), len(records))
+	}
+	e := list[0]
+	if e.Market != MARKET_SH || e.Code != "600000" || e.FenHong != 1.5 || e.Describe != "除权除息" ||
+		!e.Date.Equal(time.Date(2020, 7, 23, 0, 0, 0, 0, ChinaLocation)) || e.Year != 2020 {
+		t.Errorf("record 0 = %+v", e)
+	}
+	if e := list[2]; e.Category != 5 || e.PanHouLiuTong != 2935208 || e.QianZongGuBen != 2810376.25 {
+		t.Errorf("record 2 = %+v", e)
+	}
+	if e := list[3]; e.SuoGu != 0.5 || e.Describe != "扩缩股" {
+		t.Errorf("record 3 = %+v", e)
+	}
+
+	byCode := XdxrByCode(list)
+	if n := len(byCode[security.SecurityKey{Market: MARKET_SH, Code: "600000"}]); n != 2 {
+		t.Errorf("600000 has %d records, want 2", n)
+	}
+
+	if _, err := ReadGbbq(bytes.NewReader(data[:len(data)-1]), key); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("truncated: err = %v", err)
+	}
+	if _, err := ReadGbbq(bytes.NewReader(data), key[:100]); err == nil {
+		t.Error("short key should fail")
+	}
+	// 密钥不对时日期无法解析
+	wrong := make([]byte, GBBQ_KEY_SIZE)
+	if _, err := ReadGbbq(bytes.NewReader(data), wrong); err == nil {
+		t.Error("wrong key should fail")
+	}
+}
@@ -0,0 +1,166 @@
+package reader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	. "gotdx/imsg"
+	"io"
+	"path/filepath"
+	"time"
+)
+
+// lcRecord .lc1/.lc5 文件记录, 价格为浮点数
+type lcRecord struct {
+	Date     uint16 // (年-2004)*2048 + 月*100 + 日
+	Minute   uint16 // 零点起的分钟数
+	Open     float32
+	High     float32
+	Low      float32
+	Close    float32
+	Amount   float32
+	Vol      uint32
+	Reserved uint32
+}
+
+// minRecord 旧版 .1/.5 文件记录, 价格按 PriceScale 放大为整数
+type minRecord struct {
+	Date     uint16
+	Minute   uint16
+	Open     uint32
+	High     uint32
+	Low      uint32
+	Close    uint32
+	Amount   float32
+	Vol      uint32
+	Reserved uint32
+}
+
+// LcFilePath 分钟线文件路径, category 为 KLINE_TYPE_1MIN 时为 vipdoc/sh/minline/sh600000.lc1,
+// 否则为 vipdoc/sh/fzline/sh600000.lc5
+func LcFilePath(vipdoc string, market uint8, code string, category uint16) string {
+	dir := marketDir(market)
+	if category == KLINE_TYPE_1MIN {
+		return filepath.Join(vipdoc, dir, "minline", dir+code+".lc1")
+	}
+	return filepath.Join(vipdoc, dir, "fzline", dir+code+".lc5")
+}
+
+// ReadLcFile 读取 .lc1/.lc5 分钟线文件, 价格为浮点数, 文件名不必是证券代码
+func ReadLcFile(path string) ([]SecurityBarsElement, error) {
+	return readFile(path, lcCodec)
+}
+
+// ReadLc 读取 .lc1/.lc5 分钟线记录, K线时间为该分钟结束的时刻
+func ReadLc(r io.Reader) ([]SecurityBarsElement, error) {
+	return readBars(r, lcCodec)
+}
+
+// WriteLcFile 写 .lc1/.lc5 分钟线文件, 文件已存在时覆盖
+func WriteLcFile(path string, bars []SecurityBarsElement) error {
+	return writeFile(path, bars, lcCodec)
+}
+
+func WriteLc(w io.Writer, bars []SecurityBarsElement) error {
+	return writeBars(w, bars, lcCodec)
+}
+
+// ReadMinFile 读取旧版 .1/.5 分钟线文件, 市场和代码由文件名确定
+func ReadMinFile(path string) ([]SecurityBarsElement, error) {
+	return readBarFile(path, minCodec)
+}
+
+// ReadMin 读取旧版 .1/.5 分钟线记录, 价格除以 scale
+func ReadMin(r io.Reader, scale float64) ([]SecurityBarsElement, error) {
+	return readBars(r, minCodec(scale))
+}
+
+// WriteMinFile 写旧版 .1/.5 分钟线文件, 文件已存在时覆盖
+func WriteMinFile(path string, bars []SecurityBarsElement) error {
+	return writeBarFile(path, bars, minCodec)
+}
+
+func WriteMin(w io.Writer, bars []SecurityBarsElement, scale float64) error {
+	return writeBars(w, bars, minCodec(scale))
+}
+
+// BarsByTime 以K线时间为键索引K线
+func BarsByTime(bars []SecurityBarsElement) map[time.Time]SecurityBarsElement {
+	m := make(map[time.Time]SecurityBarsElement, len(bars))
+	for _, bar := range bars {
+		m[bar.Time()] = bar
+	}
+	return m
+}
+
+var lcCodec = recordCodec{
+	decode: func(b []byte) (SecurityBarsElement, error) {
+		var rec lcRecord
+		binary.Read(bytes.NewReader(b), binary.LittleEndian, &rec)
+		t, err := getminute(rec.Date, rec.Minute)
+		if err != nil {
+			return SecurityBarsElement{}, err
+		}
+		return newBar(t, float64(rec.Open), float64(rec.High), float64(rec.Low), float64(rec.Close),
+			float64(rec.Vol), float64(rec.Amount)), nil
+	},
+	encode: func(bar SecurityBarsElement) interface{} {
+		date, minute := putminute(bar)
+		return lcRecord{
+			Date:   date,
+			Minute: minute,
+			Open:   float32(bar.Open),
+			High:   float32(bar.High),
+			Low:    float32(bar.Low),
+			Close:  float32(bar.Close),
+			Amount: float32(bar.Amount),
+			Vol:    uint32(bar.Vol),
+		}
+	},
+}
+
+func minCodec(scale float64) recordCodec {
+	return recordCodec{
+		decode: func(b []byte) (SecurityBarsElement, error) {
+			var rec minRecord
+			binary.Read(bytes.NewReader(b), binary.LittleEndian, &rec)
+			t, err := getminute(rec.Date, rec.Minute)
+			if err != nil {
+				return SecurityBarsElement{}, err
+			}
+			return newBar(t,
+				float64(rec.Open)/scale, float64(rec.High)/scale, float64(rec.Low)/scale, float64(rec.Close)/scale,
+				float64(rec.Vol), float64(rec.Amount)), nil
+		},
+		encode: func(bar SecurityBarsElement) interface{} {
+			date, minute := putminute(bar)
+			return minRecord{
+				Date:   date,
+				Minute: minute,
+				Open:   scaled(bar.Open, scale),
+				High:   scaled(bar.High, scale),
+				Low:    scaled(bar.Low, scale),
+				Close:  scaled(bar.Close, scale),
+				Amount: float32(bar.Amount),
+				Vol:    uint32(bar.Vol),
+			}
+		},
+	}
+}
+
+// getminute 解析分钟线的压缩日期和分钟数, 与网络K线的日期格式相同
+func getminute(date, minute uint16) (time.Time, error) {
+	year := int(date>>11) + 2004
+	md := uint32(date % 2048)
+	t := getdate(uint32(year)*10000 + md)
+	if t.IsZero() || minute >= 24*60 {
+		return time.Time{}, fmt.Errorf("invalid time %d %d", date, minute)
+	}
+	return t.Add(time.Duration(minute) * time.Minute), nil
+}
+
+func putminute(bar SecurityBarsElement) (date, minute uint16) {
+	date = uint16(bar.Year-2004)<<11 | uint16(bar.Month*100+bar.Day)
+	minute = uint16(bar.Hour*60 + bar.Minute)
+	return
+}
@@ -0,0 +1,100 @@
+package reader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	. "gotdx/imsg"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReadLc(t *testing.T) {
+	// 2021-01-04 09:31 和 13:01, 日期 (2021-2004)*2048 + 104
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, lcRecord{17*2048 + 104, 571, 10.5, 10.6, 10.4, 10.55, 1.05e6, 100000, 0})
+	binary.Write(buf, binary.LittleEndian, lcRecord{17*2048 + 104, 781, 10.55, 10.6, 10.5, 10.5, 5.25e5, 50000, 0})
+
+	bars, err := ReadLc(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bars) != 2 {
+		t.Fatalf("got %d bars, want 2", len(bars))
+	}
+	want := []time.Time{
+		time.Date(2021, 1, 4, 9, 31, 0, 0, ChinaLocation),
+		time.Date(2021, 1, 4, 13, 1, 0, 0, ChinaLocation),
+	}
+	byTime := BarsByTime(bars)
+	for i, tm := range want {
+		if !bars[i].Time().Equal(tm) {
+			t.Errorf("bar %d at %v, want %v", i, bars[i].Time(), tm)
+		}
+		if _, ok := byTime[tm]; !ok {
+			t.Errorf("BarsByTime missing %v", tm)
+		}
+	}
+	if b := bars[0]; b.Open != float64(float32(10.5)) || b.Close != float64(float32(10.55)) || b.Vol != 100000 {
+		t.Errorf("bar 0 = %+v", b)
+	}
+
+	if _, err := ReadLc(bytes.NewReader(buf.Bytes()[:33])); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("truncated: err = %v", err)
+	}
+	bad := new(bytes.Buffer)
+	binary.Write(bad, binary.LittleEndian, lcRecord{Date: 17*2048 + 104, Minute: 24 * 60})
+	if _, err := ReadLc(bad); err == nil {
+		t.Error("invalid minute should fail")
+	}
+}
+
+func TestReadMin(t *testing.T) {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, minRecord{17*2048 + 104, 575, 1050, 1060, 1040, 1055, 1.05e6, 100000, 0})
+	bars, err := ReadMin(buf, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bars) != 1 || bars[0].Close != 10.55 || bars[0].DateTime != "2021-01-04 09:35:00" {
+		t.Errorf("bars = %+v", bars)
+	}
+}
+
+func TestWriteLcFile(t *testing.T) {
+	vipdoc := t.TempDir()
+	for _, category := range []uint16{KLINE_TYPE_1MIN, KLINE_TYPE_5MIN} {
+		path := LcFilePath(vipdoc, MARKET_SZ, "000001", category)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		in := []SecurityBarsElement{
+			newBar(time.Date(2021, 1, 4, 9, 35, 0, 0, ChinaLocation), 19.25, 19.5, 19.125, 19.375, 12000, 2.3e5),
+			newBar(time.Date(2021, 1, 5, 15, 0, 0, 0, ChinaLocation), 19.5, 19.75, 19.25, 19.5, 8000, 1.56e5),
+		}
+		if err := WriteLcFile(path, in); err != nil {
+			t.Fatal(err)
+		}
+		out, err := ReadBarFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(out) != len(in) || out[0] != in[0] || out[1] != in[1] {
+			t.Errorf("%s: got %+v, want %+v", filepath.Base(path), out, in)
+		}
+	}
+
+	// .lc 文件价格为浮点数, 文件名不必是证券代码
+	path := filepath.Join(vipdoc, "export.lc5")
+	if err := WriteLcFile(path, []SecurityBarsElement{newBar(time.Date(2021, 1, 4, 9, 35, 0, 0, ChinaLocation), 1, 1, 1, 1, 1, 1)}); err != nil {
+		t.Fatal(err)
+	}
+	for _, read := range []func(string) ([]SecurityBarsElement, error){ReadLcFile, ReadBarFile} {
+		if bars, err := read(path); err != nil || len(bars) != 1 {
+			t.Errorf("got %d bars, %v", len(bars), err)
+		}
+	}
+}
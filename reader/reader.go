@@ -0,0 +1,152 @@
+// Package reader 读写通达信客户端 vipdoc 目录下的本地数据文件,
+// 结果与网络接口使用相同的数据类型.
+package reader
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	. "gotdx/imsg"
+	"gotdx/security"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const RECORD_SIZE = 32 // K线文件每条记录的字节数
+
+// recordCodec K线文件单条记录的编解码
+type recordCodec struct {
+	decode func(b []byte) (SecurityBarsElement, error)
+	encode func(bar SecurityBarsElement) interface{} // 返回按小端写入的定长结构
+}
+
+// readBars 逐条读取定长记录, 最后一条不完整时返回 io.ErrUnexpectedEOF
+func readBars(r io.Reader, codec recordCodec) ([]SecurityBarsElement, error) {
+	var bars []SecurityBarsElement
+	br := bufio.NewReader(r)
+	b := make([]byte, RECORD_SIZE)
+	for {
+		if _, err := io.ReadFull(br, b); err != nil {
+			if err == io.EOF {
+				return bars, nil
+			}
+			return nil, fmt.Errorf("record %d: %w", len(bars), err)
+		}
+		bar, err := codec.decode(b)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", len(bars), err)
+		}
+		bars = append(bars, bar)
+	}
+}
+
+func writeBars(w io.Writer, bars []SecurityBarsElement, codec recordCodec) error {
+	bw := bufio.NewWriter(w)
+	for _, bar := range bars {
+		if err := binary.Write(bw, binary.LittleEndian, codec.encode(bar)); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// readBarFile 读取K线文件, 价格倍数由文件名中的市场和代码确定
+func readBarFile(path string, codec func(scale float64) recordCodec) ([]SecurityBarsElement, error) {
+	market, code, err := parseFileName(path)
+	if err != nil {
+		return nil, err
+	}
+	return readFile(path, codec(PriceScale(market, code)))
+}
+
+func readFile(path string, codec recordCodec) ([]SecurityBarsElement, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readBars(f, codec)
+}
+
+func writeBarFile(path string, bars []SecurityBarsElement, codec func(scale float64) recordCodec) error {
+	market, code, err := parseFileName(path)
+	if err != nil {
+		return err
+	}
+	return writeFile(path, bars, codec(PriceScale(market, code)))
+}
+
+func writeFile(path string, bars []SecurityBarsElement, codec recordCodec) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = writeBars(f, bars, codec); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// PriceScale 本地文件中价格的放大倍数: 股票和指数为 100, 上海B股、基金和债券为 1000
+func PriceScale(market uint8, code string) float64 {
+	switch security.ClassifySecurity(market, SecurityElement{Code: code}) {
+	case security.SecurityAShare, security.SecurityIndex:
+		return 100
+	case security.SecurityBShare:
+		if market == MARKET_SZ {
+			return 100
+		}
+	}
+	return 1000
+}
+
+// parseFileName 从 "sh600000.day" 形式的文件名取市场和代码
+func parseFileName(path string) (market uint8, code string, err error) {
+	name := filepath.Base(path)
+	return security.ParseSymbol(strings.TrimSuffix(name, filepath.Ext(name)))
+}
+
+// newBar 按时间 t 构造K线, 时间字段与网络接口一致
+func newBar(t time.Time, open, high, low, close, vol, amount float64) SecurityBarsElement {
+	return SecurityBarsElement{
+		Open:     open,
+		Close:    close,
+		High:     high,
+		Low:      low,
+		Vol:      vol,
+		Amount:   amount,
+		Year:     t.Year(),
+		Month:    int(t.Month()),
+		Day:      t.Day(),
+		Hour:     t.Hour(),
+		Minute:   t.Minute(),
+		DateTime: fmt.Sprintf("%d-%02d-%02d %02d:%02d:00", t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()),
+	}
+}
+
+// scaled 把价格按 scale 放大并四舍五入为整数
+func scaled(price, scale float64) uint32 {
+	return uint32(math.Round(price * scale))
+}
+
+func marketDir(market uint8) string {
+	if market == MARKET_SH {
+		return "sh"
+	}
+	return "sz"
+}
+
+// getdate 把 yyyymmdd 格式的日期转为北京时间零点, 日期无效时返回零值
+func getdate(d uint32) time.Time {
+	year, month, day := int(d/10000), time.Month(d%10000/100), int(d%100)
+	t := time.Date(year, month, day, 0, 0, 0, 0, ChinaLocation)
+	if year < 1990 || t.Month() != month || t.Day() != day {
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,152 @@
+package reader
+
+import (
+	"fmt"
+	. "gotdx/imsg"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// barFormat K线文件格式
+type barFormat struct {
+	category uint16
+	codec    func(scale float64) recordCodec
+	scaled   bool // 价格按 PriceScale 放大为整数, 需要从文件名取市场和代码
+}
+
+// barFormats 以扩展名区分的K线文件格式
+var barFormats = map[string]barFormat{
+	".day": {KLINE_TYPE_DAILY, dayCodec, true},
+	".lc1": {KLINE_TYPE_1MIN, func(float64) recordCodec { return lcCodec }, false},
+	".lc5": {KLINE_TYPE_5MIN, func(float64) recordCodec { return lcCodec }, false},
+	".1":   {KLINE_TYPE_1MIN, minCodec, true},
+	".5":   {KLINE_TYPE_5MIN, minCodec, true},
+}
+
+// ReadBarFile 按扩展名读取 .day、.lc1、.lc5、.1 或 .5 文件
+func ReadBarFile(path string) ([]SecurityBarsElement, error) {
+	format, ok := barFormats[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return nil, fmt.Errorf("unknown bar file %s", path)
+	}
+	if !format.scaled {
+		return readFile(path, format.codec(1))
+	}
+	return readBarFile(path, format.codec)
+}
+
+// LocalFile 本地K线文件
+type LocalFile struct {
+	Market   uint8
+	Code     string
+	Category uint16 // KLINE_TYPE_DAILY、KLINE_TYPE_1MIN 或 KLINE_TYPE_5MIN
+	Path     string
+	Count    int       // K线数量, 不含末尾不完整的记录
+	From     time.Time // 第一根K线的时间
+	To       time.Time // 最后一根K线的时间
+}
+
+// ScanErrors Scan 中无法读取的文件的错误, 每个错误都带有文件路径
+type ScanErrors []error
+
+func (e ScanErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	return fmt.Sprintf("%v (and %d more files)", e[0], len(e)-1)
+}
+
+// Scan 列出 vipdoc 下 sh、sz 的 lday、minline、fzline 目录中的K线文件及其时间范围,
+// 只读取每个文件的首尾两条记录. 空文件和文件名不是证券代码的文件不列出, 不存在的目录跳过.
+// 无法读取的文件不列出, 其错误汇总为 ScanErrors 返回, 此时其余文件照常返回.
+func Scan(vipdoc string) ([]LocalFile, error) {
+	var files []LocalFile
+	var errs ScanErrors
+	for _, market := range []string{"sh", "sz"} {
+		for _, sub := range []string{"lday", "minline", "fzline"} {
+			dir := filepath.Join(vipdoc, market, sub)
+			entries, err := ioutil.ReadDir(dir)
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return nil, err
+			}
+			for _, fi := range entries {
+				format, ok := barFormats[strings.ToLower(filepath.Ext(fi.Name()))]
+				if !ok || fi.IsDir() {
+					continue
+				}
+				if _, _, err := parseFileName(fi.Name()); err != nil {
+					continue
+				}
+				f, err := scanFile(filepath.Join(dir, fi.Name()), format)
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				if f.Count > 0 {
+					files = append(files, f)
+				}
+			}
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		a, b := files[i], files[j]
+		if a.Market != b.Market {
+			return a.Market < b.Market
+		}
+		if a.Code != b.Code {
+			return a.Code < b.Code
+		}
+		return a.Category < b.Category
+	})
+	if len(errs) > 0 {
+		return files, errs
+	}
+	return files, nil
+}
+
+func scanFile(path string, format barFormat) (LocalFile, error) {
+	market, code, err := parseFileName(path)
+	if err != nil {
+		return LocalFile{}, err
+	}
+	lf := LocalFile{Market: market, Code: code, Category: format.category, Path: path}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return lf, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return lf, fmt.Errorf("%s: %w", path, err)
+	}
+	lf.Count = int(fi.Size() / RECORD_SIZE)
+	if lf.Count == 0 {
+		return lf, nil
+	}
+
+	codec := format.codec(PriceScale(market, code))
+	b := make([]byte, RECORD_SIZE)
+	for i, off := range []int64{0, int64(lf.Count-1) * RECORD_SIZE} {
+		if _, err := f.ReadAt(b, off); err != nil {
+			return lf, fmt.Errorf("%s: %w", path, err)
+		}
+		bar, err := codec.decode(b)
+		if err != nil {
+			return lf, fmt.Errorf("%s: %w", path, err)
+		}
+		if i == 0 {
+			lf.From = bar.Time()
+		} else {
+			lf.To = bar.Time()
+		}
+	}
+	return lf, nil
+}
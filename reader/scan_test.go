@@ -0,0 +1,55 @@
+package reader
+
+import (
+	"errors"
+	. "gotdx/imsg"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScan(t *testing.T) {
+	vipdoc := t.TempDir()
+	day := func(d int) SecurityBarsElement {
+		return newBar(time.Date(2021, 1, d, 15, 0, 0, 0, ChinaLocation), 10, 10, 10, 10, 100, 1000)
+	}
+	write := func(path string, bars []SecurityBarsElement, w func(string, []SecurityBarsElement) error) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := w(path, bars); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(DayFilePath(vipdoc, MARKET_SH, "600000"), []SecurityBarsElement{day(4), day(5), day(6)}, WriteDayFile)
+	write(LcFilePath(vipdoc, MARKET_SH, "600000", KLINE_TYPE_5MIN), []SecurityBarsElement{day(5)}, WriteLcFile)
+	write(filepath.Join(vipdoc, "sz", "fzline", "sz000001.5"), []SecurityBarsElement{day(4), day(8)}, WriteMinFile)
+	write(DayFilePath(vipdoc, MARKET_SZ, "000002"), nil, WriteDayFile)
+	ioutil.WriteFile(filepath.Join(vipdoc, "sh", "lday", "readme.day"), []byte("x"), 0644)
+	// 日期无效的文件不影响其它文件
+	ioutil.WriteFile(DayFilePath(vipdoc, MARKET_SH, "600001"), make([]byte, RECORD_SIZE), 0644)
+
+	files, err := Scan(vipdoc)
+	var errs ScanErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || !strings.Contains(errs[0].Error(), "sh600001.day") {
+		t.Fatalf("err = %v, want ScanErrors for sh600001.day", err)
+	}
+	want := []LocalFile{
+		{Market: MARKET_SZ, Code: "000001", Category: KLINE_TYPE_5MIN, Count: 2, From: day(4).Time(), To: day(8).Time()},
+		{Market: MARKET_SH, Code: "600000", Category: KLINE_TYPE_5MIN, Count: 1, From: day(5).Time(), To: day(5).Time()},
+		{Market: MARKET_SH, Code: "600000", Category: KLINE_TYPE_DAILY, Count: 3, From: day(4).Time(), To: day(6).Time()},
+	}
+	if len(files) != len(want) {
+		t.Fatalf("got %+v", files)
+	}
+	for i, w := range want {
+		f := files[i]
+		if f.Market != w.Market || f.Code != w.Code || f.Category != w.Category || f.Count != w.Count ||
+			!f.From.Equal(w.From) || !f.To.Equal(w.To) {
+			t.Errorf("file %d = %+v, want %+v", i, f, w)
+		}
+	}
+}
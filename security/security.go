@@ -0,0 +1,196 @@
+// Package security 证券代码和类别的辅助函数, 不依赖网络和日志, 可供离线读取本地文件时使用.
+package security
+
+import (
+	"errors"
+	"fmt"
+	. "gotdx/imsg"
+	"strings"
+)
+
+// ErrInvalidSymbol ParseSymbol 无法解析的证券代码
+var ErrInvalidSymbol = errors.New("invalid symbol")
+
+// MarketOf 根据 6 位代码推断所属市场: 5、6、7、9 开头为上海, 其余为深圳
+func MarketOf(code string) uint8 {
+	if len(code) > 0 {
+		switch code[0] {
+		case '5', '6', '7', '9':
+			return MARKET_SH
+		}
+	}
+	return MARKET_SZ
+}
+
+// ParseSymbol 解析 "sh600000"、"600000.SH" 或 "600000" 形式的证券代码, 不区分大小写,
+// 市场可以是 sh、sz 或 bj, 不带市场时由 MarketOf 推断
+func ParseSymbol(symbol string) (market uint8, code string, err error) {
+	s := strings.ToLower(strings.TrimSpace(symbol))
+	var prefix string
+	switch {
+	case len(s) == 8:
+		prefix, code = s[:2], s[2:]
+	case len(s) == 9 && s[6] == '.':
+		code, prefix = s[:6], s[7:]
+	case len(s) == 6:
+		code = s
+	default:
+		return 0, "", fmt.Errorf("%w %q", ErrInvalidSymbol, symbol)
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return 0, "", fmt.Errorf("%w %q", ErrInvalidSymbol, symbol)
+		}
+	}
+	switch prefix {
+	case "sh":
+		return MARKET_SH, code, nil
+	case "sz":
+		return MARKET_SZ, code, nil
+	case "bj":
+		return MARKET_BJ, code, nil
+	case "":
+		return MarketOf(code), code, nil
+	}
+	return 0, "", fmt.Errorf("%w %q", ErrInvalidSymbol, symbol)
+}
+
+// Symbol 返回 "sh600000" 形式的证券代码
+func Symbol(market uint8, code string) string {
+	switch market {
+	case MARKET_SH:
+		return "sh" + code
+	case MARKET_BJ:
+		return "bj" + code
+	}
+	return "sz" + code
+}
+
+// SecurityType 证券类别
+type SecurityType int
+
+const (
+	SecurityUnknown     SecurityType = iota
+	SecurityAShare                   // A股
+	SecurityBShare                   // B股
+	SecurityIndex                    // 指数
+	SecurityETF                      // ETF
+	SecurityLOF                      // LOF
+	SecurityFund                     // 其它基金
+	SecurityBond                     // 债券
+	SecurityConvertible              // 可转债
+	SecurityRepo                     // 回购
+)
+
+var securityTypeNames = map[SecurityType]string{
+	SecurityUnknown:     "未知",
+	SecurityAShare:      "A股",
+	SecurityBShare:      "B股",
+	SecurityIndex:       "指数",
+	SecurityETF:         "ETF",
+	SecurityLOF:         "LOF",
+	SecurityFund:        "基金",
+	SecurityBond:        "债券",
+	SecurityConvertible: "可转债",
+	SecurityRepo:        "回购",
+}
+
+func (t SecurityType) String() string {
+	if name, ok := securityTypeNames[t]; ok {
+		return name
+	}
+	return securityTypeNames[SecurityUnknown]
+}
+
+// 代码前缀与类别, 较长的前缀优先匹配
+var (
+	shSecurityPrefixes = map[string]SecurityType{
+		"600": SecurityAShare, "601": SecurityAShare, "603": SecurityAShare, "605": SecurityAShare,
+		"688": SecurityAShare, "689": SecurityAShare,
+		"900": SecurityBShare,
+		"000": SecurityIndex, "880": SecurityIndex, "881": SecurityIndex, "999": SecurityIndex,
+		"510": SecurityETF, "511": SecurityETF, "512": SecurityETF, "513": SecurityETF, "515": SecurityETF,
+		"516": SecurityETF, "517": SecurityETF, "518": SecurityETF, "560": SecurityETF, "561": SecurityETF,
+		"562": SecurityETF, "563": SecurityETF, "588": SecurityETF,
+		"501": SecurityLOF, "506": SecurityLOF,
+		"500": SecurityFund, "502": SecurityFund, "505": SecurityFund, "519": SecurityFund,
+		"010": SecurityBond, "019": SecurityBond, "020": SecurityBond, "12": SecurityBond,
+		"110": SecurityConvertible, "111": SecurityConvertible, "113": SecurityConvertible, "118": SecurityConvertible,
+		"204": SecurityRepo,
+	}
+	szSecurityPrefixes = map[string]SecurityType{
+		"000": SecurityAShare, "001": SecurityAShare, "002": SecurityAShare, "003": SecurityAShare,
+		"004": SecurityAShare, "300": SecurityAShare, "301": SecurityAShare,
+		"200": SecurityBShare,
+		"399": SecurityIndex,
+		"159": SecurityETF,
+		"16":  SecurityLOF,
+		"150": SecurityFund, "184": SecurityFund,
+		"10": SecurityBond, "11": SecurityBond, "12": SecurityBond,
+		"123": SecurityConvertible, "127": SecurityConvertible, "128": SecurityConvertible,
+		"131": SecurityRepo,
+	}
+	bjSecurityPrefixes = map[string]SecurityType{
+		"43": SecurityAShare, "83": SecurityAShare, "87": SecurityAShare, "92": SecurityAShare,
+		"899": SecurityIndex,
+	}
+)
+
+// ClassifySecurity 根据代码前缀判断证券类别, 前缀无法判断时按价格精度和交易单位推断:
+// 基金价格精确到 0.001 元, 债券以 10 张为一手
+func ClassifySecurity(market uint8, e SecurityElement) SecurityType {
+	prefixes := szSecurityPrefixes
+	switch market {
+	case MARKET_SH:
+		prefixes = shSecurityPrefixes
+	case MARKET_BJ:
+		prefixes = bjSecurityPrefixes
+	}
+	for n := 3; n > 0; n-- {
+		if len(e.Code) >= n {
+			if t, ok := prefixes[e.Code[:n]]; ok {
+				return t
+			}
+		}
+	}
+	switch {
+	case e.DecimalPoint == 3:
+		return SecurityFund
+	case e.VolUnit == 10:
+		return SecurityBond
+	}
+	return SecurityUnknown
+}
+
+// SecurityKey 证券键
+type SecurityKey struct {
+	Market uint8
+	Code   string
+}
+
+// LimitRatio 证券的涨跌幅限制比例, 与 e.DecimalPoint 一起用于 SecurityQuotesElement.Limits:
+// 北交所 30%, 科创板和创业板 20%, 名称含 ST 的 5%, 其余 A股、B股和场内基金 10%, 其它类别不设限返回 0.
+// 新股上市初期等特殊情况不在此列.
+func LimitRatio(market uint8, e SecurityElement) float64 {
+	switch ClassifySecurity(market, e) {
+	case SecurityAShare:
+		switch {
+		case market == MARKET_BJ:
+			return 0.3
+		case strings.HasPrefix(e.Code, "688"), strings.HasPrefix(e.Code, "689"),
+			strings.HasPrefix(e.Code, "300"), strings.HasPrefix(e.Code, "301"):
+			return 0.2
+		case strings.Contains(strings.ToUpper(e.Name), "ST"):
+			return 0.05
+		}
+		return 0.1
+	case SecurityBShare:
+		if strings.Contains(strings.ToUpper(e.Name), "ST") {
+			return 0.05
+		}
+		return 0.1
+	case SecurityETF, SecurityLOF, SecurityFund:
+		return 0.1
+	}
+	return 0
+}
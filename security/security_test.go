@@ -0,0 +1,96 @@
+package security
+
+import (
+	"errors"
+	. "gotdx/imsg"
+	"testing"
+)
+
+func TestParseSymbol(t *testing.T) {
+	tests := []struct {
+		symbol string
+		market uint8
+		code   string
+	}{
+		{"sh600000", MARKET_SH, "600000"},
+		{"SZ000001", MARKET_SZ, "000001"},
+		{"600000.SH", MARKET_SH, "600000"},
+		{"000001.sz", MARKET_SZ, "000001"},
+		{"510300", MARKET_SH, "510300"},
+		{"300750", MARKET_SZ, "300750"},
+		{"bj430047", MARKET_BJ, "430047"},
+	}
+	for _, tt := range tests {
+		market, code, err := ParseSymbol(tt.symbol)
+		if err != nil || market != tt.market || code != tt.code {
+			t.Errorf("ParseSymbol(%q) = %d, %q, %v", tt.symbol, market, code, err)
+		}
+	}
+	for _, symbol := range []string{"", "hk000700", "60000", "600000.HK", "sh60000x"} {
+		if _, _, err := ParseSymbol(symbol); !errors.Is(err, ErrInvalidSymbol) {
+			t.Errorf("ParseSymbol(%q) err = %v, want ErrInvalidSymbol", symbol, err)
+		}
+	}
+}
+
+func TestClassifySecurity(t *testing.T) {
+	tests := []struct {
+		market uint8
+		e      SecurityElement
+		want   SecurityType
+	}{
+		{MARKET_SH, SecurityElement{Code: "600000"}, SecurityAShare},
+		{MARKET_SH, SecurityElement{Code: "688981"}, SecurityAShare},
+		{MARKET_SH, SecurityElement{Code: "900901"}, SecurityBShare},
+		{MARKET_SH, SecurityElement{Code: "000001"}, SecurityIndex},
+		{MARKET_SH, SecurityElement{Code: "510300"}, SecurityETF},
+		{MARKET_SH, SecurityElement{Code: "501018"}, SecurityLOF},
+		{MARKET_SH, SecurityElement{Code: "113050"}, SecurityConvertible},
+		{MARKET_SH, SecurityElement{Code: "122000"}, SecurityBond},
+		{MARKET_SH, SecurityElement{Code: "204001"}, SecurityRepo},
+		{MARKET_SZ, SecurityElement{Code: "000001"}, SecurityAShare},
+		{MARKET_SZ, SecurityElement{Code: "300750"}, SecurityAShare},
+		{MARKET_SZ, SecurityElement{Code: "200002"}, SecurityBShare},
+		{MARKET_SZ, SecurityElement{Code: "399001"}, SecurityIndex},
+		{MARKET_SZ, SecurityElement{Code: "159919"}, SecurityETF},
+		{MARKET_SZ, SecurityElement{Code: "161725"}, SecurityLOF},
+		{MARKET_SZ, SecurityElement{Code: "128136"}, SecurityConvertible},
+		{MARKET_SZ, SecurityElement{Code: "112000"}, SecurityBond},
+		{MARKET_SZ, SecurityElement{Code: "131810"}, SecurityRepo},
+		{MARKET_SZ, SecurityElement{Code: "180101", DecimalPoint: 3}, SecurityFund},
+		{MARKET_SZ, SecurityElement{Code: "139001", VolUnit: 10}, SecurityBond},
+		{MARKET_SZ, SecurityElement{Code: "970001", VolUnit: 100, DecimalPoint: 2}, SecurityUnknown},
+		{MARKET_BJ, SecurityElement{Code: "430047"}, SecurityAShare},
+		{MARKET_BJ, SecurityElement{Code: "920002"}, SecurityAShare},
+		{MARKET_BJ, SecurityElement{Code: "899050"}, SecurityIndex},
+	}
+	for _, tt := range tests {
+		if got := ClassifySecurity(tt.market, tt.e); got != tt.want {
+			t.Errorf("ClassifySecurity(%d, %s) = %v, want %v", tt.market, tt.e.Code, got, tt.want)
+		}
+	}
+}
+
+func TestLimitRatio(t *testing.T) {
+	tests := []struct {
+		market uint8
+		e      SecurityElement
+		want   float64
+	}{
+		{MARKET_SH, SecurityElement{Code: "600000", Name: "浦发银行"}, 0.1},
+		{MARKET_SH, SecurityElement{Code: "688981", Name: "中芯国际"}, 0.2},
+		{MARKET_SZ, SecurityElement{Code: "300750", Name: "宁德时代"}, 0.2},
+		{MARKET_SZ, SecurityElement{Code: "000004", Name: "*ST国华"}, 0.05},
+		{MARKET_SH, SecurityElement{Code: "510300", Name: "300ETF"}, 0.1},
+		{MARKET_SH, SecurityElement{Code: "000001", Name: "上证指数"}, 0},
+		{MARKET_SZ, SecurityElement{Code: "128136", Name: "立讯转债"}, 0},
+		{MARKET_BJ, SecurityElement{Code: "430047", Name: "诺思兰德"}, 0.3},
+		{MARKET_BJ, SecurityElement{Code: "832000", Name: "ST安徽"}, 0.3},
+		{MARKET_BJ, SecurityElement{Code: "899050", Name: "北证50"}, 0},
+	}
+	for _, tt := range tests {
+		if got := LimitRatio(tt.market, tt.e); got != tt.want {
+			t.Errorf("LimitRatio(%s) = %v, want %v", tt.e.Code, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package gotdx
+
+import (
+	"math/rand"
+	"time"
+)
+
+// ConnState 连接状态
+type ConnState int32
+
+const (
+	StateConnecting  ConnState = iota // 正在建立 TCP 连接
+	StateHandshaking                  // 正在发送登录命令
+	StateReady                        // 可以发送请求
+	StateBackingOff                   // 连接断开, 等待重连
+	StateClosed                       // 已关闭, 不再重连
+)
+
+var stateNames = map[ConnState]string{
+	StateConnecting:  "connecting",
+	StateHandshaking: "handshaking",
+	StateReady:       "ready",
+	StateBackingOff:  "backing-off",
+	StateClosed:      "closed",
+}
+
+func (s ConnState) String() string {
+	if name, ok := stateNames[s]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// backoff 第 attempt 次重连前的等待时间, 指数增长到 max 为止, 在 [d/2, d) 内随机抖动
+func backoff(attempt int, min, max time.Duration) time.Duration {
+	d := min
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)))
+}
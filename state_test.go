@@ -0,0 +1,183 @@
+package gotdx
+
+import (
+	"context"
+	"encoding/binary"
+	. "gotdx/imsg"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stateRecorder 记录状态变化
+type stateRecorder struct {
+	mu     sync.Mutex
+	states []ConnState
+	addrs  []string
+	ch     chan ConnState
+}
+
+func newStateRecorder() *stateRecorder {
+	return &stateRecorder{ch: make(chan ConnState, 64)}
+}
+
+func (r *stateRecorder) record(from, to ConnState, addr string) {
+	r.mu.Lock()
+	r.states = append(r.states, to)
+	r.addrs = append(r.addrs, addr)
+	r.mu.Unlock()
+	r.ch <- to
+}
+
+// wait 等待进入 state
+func (r *stateRecorder) wait(t *testing.T, state ConnState) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case s := <-r.ch:
+			if s == state {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for state %v", state)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	min, max := 100*time.Millisecond, time.Second
+	for attempt := 1; attempt <= 10; attempt++ {
+		want := min << uint(attempt-1)
+		if want > max {
+			want = max
+		}
+		for i := 0; i < 20; i++ {
+			d := backoff(attempt, min, max)
+			if d < want/2 || d >= want {
+				t.Fatalf("backoff(%d) = %v, want in [%v, %v)", attempt, d, want/2, want)
+			}
+		}
+	}
+}
+
+func TestTdxHq_Reconnect(t *testing.T) {
+	srv := newMockServer(t)
+	rec := newStateRecorder()
+	hq, err := NewTdxHqWithOptions(WithAddrs(srv.Addr()),
+		WithBackoff(10*time.Millisecond, 50*time.Millisecond), WithStateChange(rec.record))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hq.Close()
+	rec.wait(t, StateReady)
+
+	var drops int32
+	srv.Handle(KMSG_SECURITYCOUNT, func([]byte) []byte {
+		if atomic.AddInt32(&drops, 1) == 1 {
+			return nil
+		}
+		b := make([]byte, 2)
+		binary.LittleEndian.PutUint16(b, 10)
+		return b
+	})
+	if _, err := hq.SecurityCount(context.Background(), TDXSecurityCountRequest{}); !IsRetryable(err) {
+		t.Fatalf("err = %v, want retryable", err)
+	}
+	rec.wait(t, StateBackingOff)
+	rec.wait(t, StateReady)
+
+	rsp, err := hq.SecurityCount(context.Background(), TDXSecurityCountRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rsp.Count != 10 {
+		t.Errorf("Count = %d, want 10", rsp.Count)
+	}
+
+	rec.mu.Lock()
+	want := []ConnState{StateConnecting, StateHandshaking, StateReady, StateBackingOff, StateConnecting, StateHandshaking, StateReady}
+	got := rec.states
+	rec.mu.Unlock()
+	if len(got) != len(want) {
+		t.Fatalf("states = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("states = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestTdxHq_ReconnectFailover(t *testing.T) {
+	first := newMockServer(t)
+	second := newMockServer(t)
+	rec := newStateRecorder()
+	hq, err := NewTdxHqWithOptions(WithAddrs(first.Addr(), second.Addr()),
+		WithBackoff(10*time.Millisecond, 50*time.Millisecond), WithStateChange(rec.record))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hq.Close()
+	rec.wait(t, StateReady)
+
+	first.Close()
+	first.Handle(KMSG_SECURITYCOUNT, func([]byte) []byte { return nil })
+	hq.SecurityCount(context.Background(), TDXSecurityCountRequest{})
+	rec.wait(t, StateBackingOff)
+	rec.wait(t, StateReady)
+	if hq.Addr() != second.Addr() {
+		t.Errorf("reconnected to %s, want %s", hq.Addr(), second.Addr())
+	}
+}
+
+func TestTdxHq_WriteWaitsForReconnect(t *testing.T) {
+	srv := newMockServer(t)
+	rec := newStateRecorder()
+	hq, err := NewTdxHqWithOptions(WithAddrs(srv.Addr()),
+		WithBackoff(200*time.Millisecond, 200*time.Millisecond), WithStateChange(rec.record))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hq.Close()
+	rec.wait(t, StateReady)
+
+	hq.current().Close()
+	rec.wait(t, StateBackingOff)
+	srv.Handle(KMSG_SECURITYCOUNT, func([]byte) []byte { return []byte{1, 0} })
+	if _, err := hq.SecurityCount(context.Background(), TDXSecurityCountRequest{}); err != nil {
+		t.Fatalf("request during backoff: %v", err)
+	}
+}
+
+func TestTdxHq_CloseStopsReconnect(t *testing.T) {
+	srv := newMockServer(t)
+	rec := newStateRecorder()
+	hq, err := NewTdxHqWithOptions(WithAddrs(srv.Addr()),
+		WithBackoff(time.Hour, time.Hour), WithStateChange(rec.record))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.wait(t, StateReady)
+
+	hq.current().Close()
+	rec.wait(t, StateBackingOff)
+
+	done := make(chan struct{})
+	go func() {
+		hq.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close blocked while backing off")
+	}
+	if hq.State() != StateClosed {
+		t.Errorf("State() = %v, want %v", hq.State(), StateClosed)
+	}
+	if _, err := hq.SecurityCount(context.Background(), TDXSecurityCountRequest{}); err != ErrClientClosed {
+		t.Errorf("err = %v, want ErrClientClosed", err)
+	}
+}
@@ -0,0 +1,26 @@
+package gotdx
+
+import (
+	"fmt"
+	"gotdx/security"
+)
+
+// MarketOf 根据 6 位代码推断所属市场, 见 security.MarketOf
+func MarketOf(code string) uint8 {
+	return security.MarketOf(code)
+}
+
+// ParseSymbol 解析 "sh600000"、"600000.SH" 或 "600000" 形式的证券代码, 见 security.ParseSymbol.
+// 无法解析时返回的错误包含 ErrParameter
+func ParseSymbol(symbol string) (market uint8, code string, err error) {
+	market, code, err = security.ParseSymbol(symbol)
+	if err != nil {
+		return 0, "", fmt.Errorf("%v: %w", err, ErrParameter)
+	}
+	return market, code, nil
+}
+
+// Symbol 返回 "sh600000" 形式的证券代码
+func Symbol(market uint8, code string) string {
+	return security.Symbol(market, code)
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"compress/zlib"
 	"encoding/binary"
+	"fmt"
 	. "gotdx/imsg"
 	"gotdx/logger"
 	"io"
@@ -12,7 +13,7 @@ import (
 
 type TdxValueCodec struct{}
 
-func (t TdxValueCodec) Decode(raw net.Conn) (Message, error) {
+func (t TdxValueCodec) Decode(raw net.Conn) (TDXRespHeader, []byte, error) {
 	byteChan := make(chan []byte)
 	errorChan := make(chan error)
 
@@ -30,50 +31,59 @@ func (t TdxValueCodec) Decode(raw net.Conn) (Message, error) {
 	}(byteChan, errorChan)
 
 	var headerBytes []byte
+	var header TDXRespHeader
 
 	select {
 	case err := <-errorChan:
-		return nil, err
+		return header, nil, err
 
 	case headerBytes = <-byteChan:
 		if headerBytes == nil {
 			logger.Warnln("read type bytes nil")
-			return nil, ErrBadData
+			return header, nil, ErrBadData
 		}
 		headerBuf := bytes.NewReader(headerBytes)
-		var header TDXRespHeader
 		if err := binary.Read(headerBuf, binary.LittleEndian, &header); err != nil {
-			return nil, err
+			return header, nil, err
 		}
 		//	logger.Infof("%v", header)
 		if header.ZipSize > MessageMaxBytes {
 			logger.Errorf("msgData has bytes(%d) beyond max %d\n", header.ZipSize, MessageMaxBytes)
-			return nil, ErrBadData
+			return header, nil, ErrBadData
 		}
 
 		msgData := make([]byte, header.ZipSize)
 		_, err := io.ReadFull(raw, msgData)
 		if err != nil {
-			return nil, err
+			return header, nil, err
 		}
 
-		var out bytes.Buffer
 		if header.ZipSize != header.UnZipSize {
-			b := bytes.NewReader(msgData)
-			r, _ := zlib.NewReader(b)
-			io.Copy(&out, r)
+			var out bytes.Buffer
+			r, err := zlib.NewReader(bytes.NewReader(msgData))
+			if err != nil {
+				return header, nil, ErrDecode{Type: int32(header.Type), Err: err}
+			}
+			if _, err = io.Copy(&out, r); err != nil {
+				return header, nil, ErrDecode{Type: int32(header.Type), Err: err}
+			}
+			msgData = out.Bytes()
 		}
+		return header, msgData, nil
+	}
+}
 
-		msg := GetMessage(int32(header.Type))
-
-		if msg == nil {
-			return nil, ErrUndefined(int32(header.Type))
-		}
-		if header.ZipSize != header.UnZipSize {
-			return msg, msg.UnSerialize(header, out.Bytes())
+// unserialize 解码响应体, 将越界等 panic 转换为 ErrDecode
+func unserialize(msg Message, header TDXRespHeader, b []byte) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = ErrDecode{Type: int32(header.Type), Err: fmt.Errorf("%v", p)}
 		}
-		return msg, msg.UnSerialize(header, msgData)
+	}()
+	if err = msg.UnSerialize(header, b); err != nil {
+		return ErrDecode{Type: int32(header.Type), Err: err}
 	}
+	return nil
 }
 
 func (t TdxValueCodec) Encode(message Message) ([]byte, error) {
@@ -0,0 +1,176 @@
+package gotdx
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	. "gotdx/imsg"
+	"gotdx/logger"
+	"net"
+	"sync"
+	"time"
+)
+
+// pendingCall 等待响应的请求
+type pendingCall struct {
+	message Message
+	done    chan error
+}
+
+// tdxConn 单条 TCP 连接. 请求可以并发写入, 读协程按 SeqID 把响应分发给对应的请求.
+type tdxConn struct {
+	addr    string
+	raw     net.Conn
+	codec   Codec
+	sending chan bool // 写锁
+
+	mu      sync.Mutex
+	pending map[uint32]*pendingCall
+	err     error // 读协程退出原因
+
+	closed  chan struct{} // 读协程退出后关闭
+	once    sync.Once
+	onFrame func() // 每收到一个响应帧调用, 用于维持心跳
+}
+
+func newTdxConn(addr string, raw net.Conn, codec Codec, onFrame func()) *tdxConn {
+	c := &tdxConn{
+		addr:    addr,
+		raw:     raw,
+		codec:   codec,
+		sending: make(chan bool, 1),
+		pending: make(map[uint32]*pendingCall),
+		closed:  make(chan struct{}),
+		onFrame: onFrame,
+	}
+	go c.readLoop()
+	return c
+}
+
+// readLoop 读取响应帧并交给 SeqID 对应的请求, 连接出错时让所有等待中的请求失败
+func (c *tdxConn) readLoop() {
+	var err error
+	defer func() {
+		c.mu.Lock()
+		c.err = ErrConnReset{Err: err}
+		pending := c.pending
+		c.pending = make(map[uint32]*pendingCall)
+		c.mu.Unlock()
+		for _, call := range pending {
+			call.done <- c.err
+		}
+		c.Close()
+		close(c.closed)
+		logger.Debugln("read go-routine exited", c.addr, err)
+	}()
+
+	for {
+		var header TDXRespHeader
+		var body []byte
+		header, body, err = c.codec.Decode(c.raw)
+		if err != nil {
+			if _, ok := err.(ErrDecode); !ok {
+				return
+			}
+			logger.Errorf("error decoding message %v\n", err)
+		}
+		if c.onFrame != nil {
+			c.onFrame()
+		}
+
+		c.mu.Lock()
+		call, ok := c.pending[header.SeqID]
+		delete(c.pending, header.SeqID)
+		c.mu.Unlock()
+		if !ok {
+			logger.Warnf("drop response type %d seq %d without waiter\n", header.Type, header.SeqID)
+			continue
+		}
+
+		switch {
+		case err != nil:
+			call.done <- err
+			err = nil
+		case int32(header.Type) != call.message.MessageNumber():
+			call.done <- ErrUnexpectedMessage{Want: call.message.MessageNumber(), Got: int32(header.Type)}
+		default:
+			call.done <- unserialize(call.message, header, body)
+		}
+	}
+}
+
+// roundTrip 发送 message 并等待同一 SeqID 的响应解码到 message 中
+func (c *tdxConn) roundTrip(ctx context.Context, message Message) error {
+	pkt, err := c.codec.Encode(message)
+	if err != nil {
+		return err
+	}
+	if len(pkt) < 5 {
+		return fmt.Errorf("message %d: packet too short", message.MessageNumber())
+	}
+	seq := binary.LittleEndian.Uint32(pkt[1:5])
+
+	call := &pendingCall{message: message, done: make(chan error, 1)}
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return c.err
+	}
+	if _, ok := c.pending[seq]; ok {
+		c.mu.Unlock()
+		return fmt.Errorf("message %d: seq %d already in flight", message.MessageNumber(), seq)
+	}
+	c.pending[seq] = call
+	c.mu.Unlock()
+
+	if err = c.write(ctx, pkt); err != nil {
+		c.forget(seq)
+		return err
+	}
+
+	select {
+	case err = <-call.done:
+		return err
+	case <-ctx.Done():
+		c.forget(seq)
+		return connError(ctx, ctx.Err())
+	}
+}
+
+func (c *tdxConn) write(ctx context.Context, pkt []byte) error {
+	select {
+	case c.sending <- true:
+	case <-ctx.Done():
+		return connError(ctx, ctx.Err())
+	case <-c.closed:
+		return c.err
+	}
+	defer func() { <-c.sending }()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		c.raw.SetWriteDeadline(deadline)
+		defer c.raw.SetWriteDeadline(time.Time{})
+	}
+	if _, err := c.raw.Write(pkt); err != nil {
+		// 写入了部分数据后的连接无法继续使用
+		c.Close()
+		return connError(ctx, err)
+	}
+	return nil
+}
+
+func (c *tdxConn) forget(seq uint32) {
+	c.mu.Lock()
+	delete(c.pending, seq)
+	c.mu.Unlock()
+}
+
+// Close 关闭连接, 读协程随后退出
+func (c *tdxConn) Close() error {
+	var err error
+	c.once.Do(func() {
+		logger.Infof("conn close gracefully, <%v -> %v>\n", c.raw.LocalAddr(), c.raw.RemoteAddr())
+		err = c.raw.Close()
+	})
+	return err
+}
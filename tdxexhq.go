@@ -0,0 +1,185 @@
+package gotdx
+
+import (
+	"context"
+	. "gotdx/imsg"
+	"gotdx/logger"
+)
+
+var _ ITdxEXHq = (*TdxExHq)(nil)
+
+// TdxExHq 拓展行情连接. 连接、重连和心跳与 TdxHq 相同, 只是登录命令和心跳请求不同.
+type TdxExHq struct {
+	hq *TdxHq
+}
+
+func NewTdxExHq() ITdxEXHq {
+	t, err := NewTdxExHqWithOptions()
+	if err != nil {
+		logger.Fatalln(err)
+	}
+	return t
+}
+
+// NewTdxExHqWithOptions 按参数创建拓展行情连接, 没有指定服务器时连接 DEFAULT_EXHQ_ADDR,
+// 可用 WithConfig(&cfg, config.EXHQHOST) 使用 connect.cfg 中的拓展行情服务器
+func NewTdxExHqWithOptions(opts ...Option) (*TdxExHq, error) {
+	hq := newTdxHq(newOptions(DEFAULT_EXHQ_ADDR, opts))
+	hq.handshake = func() []Message {
+		return []Message{NewExSetupMessage()}
+	}
+	hq.heartbeat = func() Message {
+		return NewTDXExInstrumentCountMessage()
+	}
+	if err := hq.start(); err != nil {
+		return nil, err
+	}
+	return &TdxExHq{hq: hq}, nil
+}
+
+// Addr 当前连接的服务器地址
+func (t *TdxExHq) Addr() string {
+	return t.hq.Addr()
+}
+
+// State 当前连接状态
+func (t *TdxExHq) State() ConnState {
+	return t.hq.State()
+}
+
+// Close 关闭连接并停止心跳和重连
+func (t *TdxExHq) Close() error {
+	return t.hq.Close()
+}
+
+func (t *TdxExHq) Markets(ctx context.Context) (TDXExMarketsResponse, error) {
+	msg, err := t.hq.Write(ctx, NewTDXExMarketsMessage())
+	if err != nil {
+		return TDXExMarketsResponse{}, err
+	}
+	sub, ok := msg.(*TDXExMarketsMessage)
+	if !ok {
+		return TDXExMarketsResponse{}, unexpectedMessage(KMSG_EXMARKETS, msg)
+	}
+	return sub.TDXExMarketsResponse, nil
+}
+
+func (t *TdxExHq) InstrumentCount(ctx context.Context) (TDXExInstrumentCountResponse, error) {
+	msg, err := t.hq.Write(ctx, NewTDXExInstrumentCountMessage())
+	if err != nil {
+		return TDXExInstrumentCountResponse{}, err
+	}
+	sub, ok := msg.(*TDXExInstrumentCountMessage)
+	if !ok {
+		return TDXExInstrumentCountResponse{}, unexpectedMessage(KMSG_EXINSTRUMENTCOUNT, msg)
+	}
+	return sub.TDXExInstrumentCountResponse, nil
+}
+
+func (t *TdxExHq) InstrumentInfo(ctx context.Context, req TDXExInstrumentInfoRequest) (TDXExInstrumentInfoResponse, error) {
+	msg, err := t.hq.Write(ctx, NewTDXExInstrumentInfoMessage(req))
+	if err != nil {
+		return TDXExInstrumentInfoResponse{}, err
+	}
+	sub, ok := msg.(*TDXExInstrumentInfoMessage)
+	if !ok {
+		return TDXExInstrumentInfoResponse{}, unexpectedMessage(KMSG_EXINSTRUMENTINFO, msg)
+	}
+	return sub.TDXExInstrumentInfoResponse, nil
+}
+
+func (t *TdxExHq) InstrumentQuote(ctx context.Context, req TDXExInstrumentQuoteRequest) (TDXExInstrumentQuoteResponse, error) {
+	msg, err := t.hq.Write(ctx, NewTDXExInstrumentQuoteMessage(req))
+	if err != nil {
+		return TDXExInstrumentQuoteResponse{}, err
+	}
+	sub, ok := msg.(*TDXExInstrumentQuoteMessage)
+	if !ok {
+		return TDXExInstrumentQuoteResponse{}, unexpectedMessage(KMSG_EXINSTRUMENTQUOTE, msg)
+	}
+	return sub.TDXExInstrumentQuoteResponse, nil
+}
+
+func (t *TdxExHq) InstrumentQuoteList(ctx context.Context, req TDXExInstrumentQuoteListRequest) (TDXExInstrumentQuoteListResponse, error) {
+	msg, err := t.hq.Write(ctx, NewTDXExInstrumentQuoteListMessage(req))
+	if err != nil {
+		return TDXExInstrumentQuoteListResponse{}, err
+	}
+	sub, ok := msg.(*TDXExInstrumentQuoteListMessage)
+	if !ok {
+		return TDXExInstrumentQuoteListResponse{}, unexpectedMessage(KMSG_EXINSTRUMENTQUOTELIST, msg)
+	}
+	return sub.TDXExInstrumentQuoteListResponse, nil
+}
+
+func (t *TdxExHq) InstrumentBars(ctx context.Context, req TDXExInstrumentBarsRequest) (TDXExInstrumentBarsResponse, error) {
+	msg, err := t.hq.Write(ctx, NewTDXExInstrumentBarsMessage(req))
+	if err != nil {
+		return TDXExInstrumentBarsResponse{}, err
+	}
+	sub, ok := msg.(*TDXExInstrumentBarsMessage)
+	if !ok {
+		return TDXExInstrumentBarsResponse{}, unexpectedMessage(KMSG_EXINSTRUMENTBARS, msg)
+	}
+	return sub.TDXExInstrumentBarsResponse, nil
+}
+
+func (t *TdxExHq) HistoryInstrumentBarsRange(ctx context.Context, req TDXExHistoryInstrumentBarsRangeRequest) (TDXExHistoryInstrumentBarsRangeResponse, error) {
+	msg, err := t.hq.Write(ctx, NewTDXExHistoryInstrumentBarsRangeMessage(req))
+	if err != nil {
+		return TDXExHistoryInstrumentBarsRangeResponse{}, err
+	}
+	sub, ok := msg.(*TDXExHistoryInstrumentBarsRangeMessage)
+	if !ok {
+		return TDXExHistoryInstrumentBarsRangeResponse{}, unexpectedMessage(KMSG_EXHISTORYINSTRUMENTBARSRANGE, msg)
+	}
+	return sub.TDXExHistoryInstrumentBarsRangeResponse, nil
+}
+
+func (t *TdxExHq) MinuteTimeData(ctx context.Context, req TDXExMinuteTimeDataRequest) (TDXExMinuteTimeDataResponse, error) {
+	msg, err := t.hq.Write(ctx, NewTDXExMinuteTimeDataMessage(req))
+	if err != nil {
+		return TDXExMinuteTimeDataResponse{}, err
+	}
+	sub, ok := msg.(*TDXExMinuteTimeDataMessage)
+	if !ok {
+		return TDXExMinuteTimeDataResponse{}, unexpectedMessage(KMSG_EXMINUTETIMEDATA, msg)
+	}
+	return sub.TDXExMinuteTimeDataResponse, nil
+}
+
+func (t *TdxExHq) HistoryMinuteTimeData(ctx context.Context, req TDXExHistoryMinuteTimeDataRequest) (TDXExHistoryMinuteTimeDataResponse, error) {
+	msg, err := t.hq.Write(ctx, NewTDXExHistoryMinuteTimeDataMessage(req))
+	if err != nil {
+		return TDXExHistoryMinuteTimeDataResponse{}, err
+	}
+	sub, ok := msg.(*TDXExHistoryMinuteTimeDataMessage)
+	if !ok {
+		return TDXExHistoryMinuteTimeDataResponse{}, unexpectedMessage(KMSG_EXHISTORYMINUTETIMEDATA, msg)
+	}
+	return sub.TDXExHistoryMinuteTimeDataResponse, nil
+}
+
+func (t *TdxExHq) TransactionData(ctx context.Context, req TDXExTransactionDataRequest) (TDXExTransactionDataResponse, error) {
+	msg, err := t.hq.Write(ctx, NewTDXExTransactionDataMessage(req))
+	if err != nil {
+		return TDXExTransactionDataResponse{}, err
+	}
+	sub, ok := msg.(*TDXExTransactionDataMessage)
+	if !ok {
+		return TDXExTransactionDataResponse{}, unexpectedMessage(KMSG_EXTRANSACTIONDATA, msg)
+	}
+	return sub.TDXExTransactionDataResponse, nil
+}
+
+func (t *TdxExHq) HistoryTransactionData(ctx context.Context, req TDXExHistoryTransactionDataRequest) (TDXExHistoryTransactionDataResponse, error) {
+	msg, err := t.hq.Write(ctx, NewTDXExHistoryTransactionDataMessage(req))
+	if err != nil {
+		return TDXExHistoryTransactionDataResponse{}, err
+	}
+	sub, ok := msg.(*TDXExHistoryTransactionDataMessage)
+	if !ok {
+		return TDXExHistoryTransactionDataResponse{}, unexpectedMessage(KMSG_EXHISTORYTRANSACTIONDATA, msg)
+	}
+	return sub.TDXExHistoryTransactionDataResponse, nil
+}
@@ -0,0 +1,296 @@
+package gotdx
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	. "gotdx/imsg"
+	"math"
+	"sync/atomic"
+	"testing"
+)
+
+func newMockExHq(t *testing.T) (*TdxExHq, *mockServer) {
+	srv := newMockServer(t)
+	hq, err := NewTdxExHqWithOptions(WithAddrs(srv.Addr()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { hq.Close() })
+	return hq, srv
+}
+
+// exCode 9 字节代码, 不足补 0
+func exCode(code string) []byte {
+	b := make([]byte, 9)
+	copy(b, code)
+	return b
+}
+
+func TestTdxExHq_Setup(t *testing.T) {
+	srv := newMockServer(t)
+	var setups, cmd1 int32
+	srv.Handle(KMSG_EXSETUP, func([]byte) []byte {
+		atomic.AddInt32(&setups, 1)
+		return []byte{0}
+	})
+	srv.Handle(KMSG_CMD1, func([]byte) []byte {
+		atomic.AddInt32(&cmd1, 1)
+		return []byte{0}
+	})
+	hq, err := NewTdxExHqWithOptions(WithAddrs(srv.Addr()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hq.Close()
+	if atomic.LoadInt32(&setups) != 1 || atomic.LoadInt32(&cmd1) != 0 {
+		t.Errorf("setup commands = %d, cmd1 = %d, want 1 and 0", setups, cmd1)
+	}
+	if hq.State() != StateReady {
+		t.Errorf("State() = %v, want %v", hq.State(), StateReady)
+	}
+}
+
+func TestTdxExHq_Markets(t *testing.T) {
+	hq, srv := newMockExHq(t)
+	srv.Handle(KMSG_EXMARKETS, func([]byte) []byte {
+		buf := new(bytes.Buffer)
+		binary.Write(buf, binary.LittleEndian, uint16(3))
+		for _, m := range []struct {
+			category, market byte
+			name, short      string
+		}{{3, 28, "郑州商品", "QZ"}, {0, 0, "", ""}, {2, 31, "香港主板", "KH"}} {
+			var rec [62]byte
+			rec[0] = m.category
+			copy(rec[1:33], gbkString(m.name))
+			rec[33] = m.market
+			copy(rec[34:36], m.short)
+			buf.Write(rec[:])
+		}
+		return buf.Bytes()
+	})
+
+	rsp, err := hq.Markets(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ExMarketElement{
+		{Market: 28, Category: 3, Name: "郑州商品", ShortName: "QZ"},
+		{Market: 31, Category: 2, Name: "香港主板", ShortName: "KH"},
+	}
+	if int(rsp.Num) != len(want) || len(rsp.List) != len(want) {
+		t.Fatalf("got %+v", rsp)
+	}
+	for i := range want {
+		if rsp.List[i] != want[i] {
+			t.Errorf("market %d = %+v, want %+v", i, rsp.List[i], want[i])
+		}
+	}
+}
+
+func TestTdxExHq_InstrumentCountAndInfo(t *testing.T) {
+	hq, srv := newMockExHq(t)
+	srv.Handle(KMSG_EXINSTRUMENTCOUNT, func([]byte) []byte {
+		b := make([]byte, 23)
+		binary.LittleEndian.PutUint32(b[19:], 54321)
+		return b
+	})
+	srv.Handle(KMSG_EXINSTRUMENTINFO, func(req []byte) []byte {
+		start := binary.LittleEndian.Uint32(req[0:4])
+		buf := new(bytes.Buffer)
+		binary.Write(buf, binary.LittleEndian, start)
+		binary.Write(buf, binary.LittleEndian, uint16(1))
+		var rec [64]byte
+		rec[0], rec[1] = 3, 30
+		copy(rec[5:14], "RBL8")
+		copy(rec[14:31], gbkString("螺纹钢主连"))
+		copy(rec[31:40], "SHFE")
+		buf.Write(rec[:])
+		return buf.Bytes()
+	})
+
+	count, err := hq.InstrumentCount(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count.Count != 54321 {
+		t.Errorf("Count = %d, want 54321", count.Count)
+	}
+	info, err := hq.InstrumentInfo(context.Background(), TDXExInstrumentInfoRequest{Start: 100, Count: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ExInstrumentInfoElement{Category: 3, Market: 30, Code: "RBL8", Name: "螺纹钢主连", Desc: "SHFE"}
+	if info.Start != 100 || len(info.List) != 1 || info.List[0] != want {
+		t.Errorf("info = %+v", info)
+	}
+}
+
+func TestTdxExHq_InstrumentQuote(t *testing.T) {
+	hq, srv := newMockExHq(t)
+	srv.Handle(KMSG_EXINSTRUMENTQUOTE, func(req []byte) []byte {
+		buf := new(bytes.Buffer)
+		buf.Write(req[:10])
+		buf.Write(make([]byte, 4))
+		f := math.Float32bits
+		binary.Write(buf, binary.LittleEndian, []uint32{f(3800), f(3810), f(3850), f(3790), f(3820),
+			120, 0, 5000, 3, f(1.9e9), 2400, 2600, 0, 180000})
+		for i := uint32(0); i < 5; i++ {
+			binary.Write(buf, binary.LittleEndian, f(3819-float32(i)))
+		}
+		binary.Write(buf, binary.LittleEndian, []uint32{10, 20, 30, 40, 50})
+		for i := uint32(0); i < 5; i++ {
+			binary.Write(buf, binary.LittleEndian, f(3820+float32(i)))
+		}
+		binary.Write(buf, binary.LittleEndian, []uint32{11, 21, 31, 41, 51})
+		return buf.Bytes()
+	})
+
+	rsp, err := hq.InstrumentQuote(context.Background(), NewTDXExInstrumentQuoteRequest(30, "RBL8"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rsp.Market != 30 || rsp.Code != "RBL8" {
+		t.Errorf("instrument = %d %s", rsp.Market, rsp.Code)
+	}
+	if rsp.PreClose != 3800 || rsp.Open != 3810 || rsp.High != 3850 || rsp.Low != 3790 || rsp.Price != 3820 {
+		t.Errorf("prices = %+v", rsp)
+	}
+	if rsp.KaiCang != 120 || rsp.ZongLiang != 5000 || rsp.XianLiang != 3 || rsp.ZongJinE != float64(float32(1.9e9)) ||
+		rsp.NeiPan != 2400 || rsp.WaiPan != 2600 || rsp.ChiCang != 180000 {
+		t.Errorf("volumes = %+v", rsp)
+	}
+	if rsp.BidLevels[0] != (Level{Price: 3819, Vol: 10}) || rsp.OfferLevels[4] != (Level{Price: 3824, Vol: 51}) {
+		t.Errorf("levels = %+v %+v", rsp.BidLevels, rsp.OfferLevels)
+	}
+}
+
+func TestTdxExHq_InstrumentBars(t *testing.T) {
+	hq, srv := newMockExHq(t)
+	srv.Handle(KMSG_EXINSTRUMENTBARS, func(req []byte) []byte {
+		buf := new(bytes.Buffer)
+		buf.Write(req[:18])
+		binary.Write(buf, binary.LittleEndian, uint16(2))
+		f := math.Float32bits
+		for i, date := range []uint32{20210104, 20210105} {
+			binary.Write(buf, binary.LittleEndian, date)
+			binary.Write(buf, binary.LittleEndian, []uint32{f(4000), f(4100), f(3950), f(4050),
+				uint32(150000 + i), uint32(80000 + i), f(4020)})
+		}
+		return buf.Bytes()
+	})
+
+	rsp, err := hq.InstrumentBars(context.Background(), NewTDXExInstrumentBarsRequest(30, "RBL8", KLINE_TYPE_DAILY, 0, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rsp.List) != 2 {
+		t.Fatalf("got %d bars, want 2", len(rsp.List))
+	}
+	bar := rsp.List[1]
+	if bar.DateTime != "2021-01-05 15:00:00" {
+		t.Errorf("DateTime = %s", bar.DateTime)
+	}
+	if bar.Open != 4000 || bar.High != 4100 || bar.Low != 3950 || bar.Close != 4050 ||
+		bar.Position != 150001 || bar.Trade != 80001 || bar.Price != 4020 {
+		t.Errorf("bar = %+v", bar)
+	}
+}
+
+func TestTdxExHq_MinuteAndTransactions(t *testing.T) {
+	hq, srv := newMockExHq(t)
+	srv.Handle(KMSG_EXMINUTETIMEDATA, func(req []byte) []byte {
+		// 实时分时: 市场1 代码9 数量2, 没有历史分时中的 8 个未知字节
+		buf := new(bytes.Buffer)
+		buf.Write(req[:10])
+		binary.Write(buf, binary.LittleEndian, uint16(1))
+		binary.Write(buf, binary.LittleEndian, uint16(21*60+1))
+		binary.Write(buf, binary.LittleEndian, []uint32{math.Float32bits(4050), math.Float32bits(4049.5), 300, 180000})
+		return buf.Bytes()
+	})
+	srv.Handle(KMSG_EXTRANSACTIONDATA, func(req []byte) []byte {
+		buf := new(bytes.Buffer)
+		buf.Write(req[:10])
+		buf.Write(make([]byte, 4))
+		binary.Write(buf, binary.LittleEndian, uint16(1))
+		binary.Write(buf, binary.LittleEndian, uint16(9*60+30))
+		binary.Write(buf, binary.LittleEndian, []uint32{4050, 12})
+		binary.Write(buf, binary.LittleEndian, int32(-4))
+		binary.Write(buf, binary.LittleEndian, uint16(10012))
+		return buf.Bytes()
+	})
+
+	minutes, err := hq.MinuteTimeData(context.Background(), NewTDXExMinuteTimeDataRequest(30, "RBL8"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ExMinuteTimeElement{Hour: 21, Minute: 1, Price: 4050, AvgPrice: 4049.5, Volume: 300, OpenInterest: 180000}
+	if len(minutes.List) != 1 || minutes.List[0] != want {
+		t.Errorf("minutes = %+v", minutes)
+	}
+
+	ticks, err := hq.TransactionData(context.Background(), NewTDXExTransactionDataRequest(30, "RBL8", 0, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ticks.List) != 1 {
+		t.Fatalf("got %d ticks, want 1", len(ticks.List))
+	}
+	tick := ticks.List[0]
+	if tick.Hour != 9 || tick.Minute != 30 || tick.Price != 4050 || tick.Volume != 12 ||
+		tick.ZengCang != -4 || tick.NatureRaw != 10012 {
+		t.Errorf("tick = %+v", tick)
+	}
+}
+
+func TestTdxExHq_History(t *testing.T) {
+	hq, srv := newMockExHq(t)
+	srv.Handle(KMSG_EXHISTORYMINUTETIMEDATA, func(req []byte) []byte {
+		if date := binary.LittleEndian.Uint32(req[0:4]); date != 20210104 {
+			t.Errorf("date = %d", date)
+		}
+		buf := new(bytes.Buffer)
+		buf.Write(req[4:14])
+		buf.Write(make([]byte, 8))
+		binary.Write(buf, binary.LittleEndian, uint16(2))
+		for i := 0; i < 2; i++ {
+			binary.Write(buf, binary.LittleEndian, uint16(9*60+i))
+			binary.Write(buf, binary.LittleEndian, []uint32{math.Float32bits(4000), math.Float32bits(4000), 10, 100})
+		}
+		return buf.Bytes()
+	})
+	srv.Handle(KMSG_EXHISTORYTRANSACTIONDATA, func(req []byte) []byte {
+		if date := binary.LittleEndian.Uint32(req[0:4]); date != 20210104 {
+			t.Errorf("date = %d", date)
+		}
+		buf := new(bytes.Buffer)
+		buf.Write(req[4:14])
+		buf.Write(make([]byte, 4))
+		binary.Write(buf, binary.LittleEndian, uint16(1))
+		binary.Write(buf, binary.LittleEndian, uint16(14*60+59))
+		binary.Write(buf, binary.LittleEndian, []uint32{4000, 8})
+		binary.Write(buf, binary.LittleEndian, int32(8))
+		binary.Write(buf, binary.LittleEndian, uint16(10042))
+		return buf.Bytes()
+	})
+
+	minutes, err := hq.HistoryMinuteTimeData(context.Background(), NewTDXExHistoryMinuteTimeDataRequest(20210104, 30, "RBL8"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(minutes.List) != 2 || minutes.List[1].Minute != 1 || minutes.List[1].OpenInterest != 100 {
+		t.Errorf("minutes = %+v", minutes)
+	}
+
+	ticks, err := hq.HistoryTransactionData(context.Background(), NewTDXExHistoryTransactionDataRequest(20210104, 30, "RBL8", 0, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ticks.List) != 1 {
+		t.Fatalf("got %d ticks, want 1", len(ticks.List))
+	}
+	tick := ticks.List[0]
+	if tick.Hour != 14 || tick.Minute != 59 || tick.Second != 42 || tick.Direction != -1 || tick.Nature != NatureDualOpen {
+		t.Errorf("tick = %+v", tick)
+	}
+}
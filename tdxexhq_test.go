@@ -0,0 +1,68 @@
+package gotdx
+
+import (
+	. "gotdx/imsg"
+	"testing"
+)
+
+var (
+	extdx    ITdxEXHq
+	extdxErr error
+)
+
+// connectExHq 连接拓展行情服务器, 连接失败时跳过需要网络的测试
+func connectExHq(t *testing.T) {
+	if extdx == nil && extdxErr == nil {
+		var hq *TdxExHq
+		if hq, extdxErr = NewTdxExHqWithOptions(); extdxErr == nil {
+			extdx = hq
+		}
+	}
+	if extdxErr != nil {
+		t.Skip(extdxErr)
+	}
+}
+
+func TestTdxExHq_Catalogue(t *testing.T) {
+	connectExHq(t)
+	if _, err := extdx.Markets(ctx); err != nil {
+		t.Error(err)
+	}
+	if _, err := extdx.InstrumentCount(ctx); err != nil {
+		t.Error(err)
+	}
+	if _, err := extdx.InstrumentInfo(ctx, TDXExInstrumentInfoRequest{Start: 0, Count: 100}); err != nil {
+		t.Error(err)
+	}
+	if _, err := LoadExCatalogue(ctx, extdx); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTdxExHq_Data(t *testing.T) {
+	connectExHq(t)
+	if _, err := extdx.InstrumentQuote(ctx, NewTDXExInstrumentQuoteRequest(47, "IFL0")); err != nil {
+		t.Error(err)
+	}
+	if _, err := extdx.InstrumentQuoteList(ctx, NewTDXExInstrumentQuoteListRequest(47, EX_CATEGORY_FUTURES, 0, EX_QUOTE_LIST_PAGE)); err != nil {
+		t.Error(err)
+	}
+	if _, err := extdx.InstrumentBars(ctx, NewTDXExInstrumentBarsRequest(47, "IFL0", KLINE_TYPE_DAILY, 0, 100)); err != nil {
+		t.Error(err)
+	}
+	if _, err := extdx.HistoryInstrumentBarsRange(ctx, NewTDXExHistoryInstrumentBarsRangeRequest(47, "IFL0", 20210104, 20210108)); err != nil {
+		t.Error(err)
+	}
+	if _, err := extdx.MinuteTimeData(ctx, NewTDXExMinuteTimeDataRequest(47, "IFL0")); err != nil {
+		t.Error(err)
+	}
+	if _, err := extdx.TransactionData(ctx, NewTDXExTransactionDataRequest(47, "IFL0", 0, 100)); err != nil {
+		t.Error(err)
+	}
+	if _, err := extdx.HistoryMinuteTimeData(ctx, NewTDXExHistoryMinuteTimeDataRequest(20210104, 47, "IFL0")); err != nil {
+		t.Error(err)
+	}
+	if _, err := extdx.HistoryTransactionData(ctx, NewTDXExHistoryTransactionDataRequest(20210104, 47, "IFL0", 0, 100)); err != nil {
+		t.Error(err)
+	}
+}
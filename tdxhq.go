@@ -2,13 +2,13 @@ package gotdx
 
 import (
 	"bytes"
-	"encoding/binary"
-	"github.com/axgle/mahonia"
+	"context"
+	"fmt"
 	. "gotdx/imsg"
 	"gotdx/logger"
 	"math/rand"
-	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -18,49 +18,94 @@ const (
 )
 
 const (
-	RECONNECT_INTERVAL = 3 // 重连时间
+	RECONNECT_INTERVAL     = 3  // 重连时间
+	RECONNECT_MAX_INTERVAL = 60 // 最长重连间隔
 )
 
 func NewTdxHq() ITdxHq {
-	t := &TdxHq{
-		tdxcodec: TdxValueCodec{},
-		heart:    time.Now().UnixNano(),
+	t, err := NewTdxHqWithOptions()
+	if err != nil {
+		logger.Fatalln(err)
 	}
-	t.start()
-	<-t.complete
 	return t
 }
 
+// NewTdxHqWithOptions 按参数创建行情连接, 依次尝试地址列表, 全部失败时返回错误
+func NewTdxHqWithOptions(opts ...Option) (*TdxHq, error) {
+	t := newTdxHq(newOptions(DEFAULT_HQ_ADDR, opts))
+	if err := t.start(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func newTdxHq(o Options) *TdxHq {
+	return &TdxHq{
+		opts:     o,
+		tdxcodec: TdxValueCodec{},
+		handshake: func() []Message {
+			return []Message{NewCMD1Message(), NewCMD2Message()}
+		},
+		heartbeat: func() Message {
+			return NewTDXSecurityCountMessage(TDXSecurityCountRequest{Market: rand.Int31n(2)})
+		},
+		heart: time.Now().UnixNano(),
+		state: StateBackingOff,
+		ready: make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+}
+
 type TdxHq struct {
-	complete       chan bool
-	sending        chan bool
-	addr           string
-	rawConn        net.Conn
-	heart          int64
-	tdxcodec       Codec
-	once           *sync.Once
-	wg             *sync.WaitGroup
-	HeartBeatTimer *time.Ticker //  维持心跳
+	opts     Options
+	heart    int64
+	tdxcodec Codec
+	next     int // 下一个重连地址的序号
+
+	handshake func() []Message // 建立连接后依次发送的登录命令
+	heartbeat func() Message   // 空闲时发送的心跳请求
+
+	mu    sync.RWMutex
+	state ConnState
+	conn  *tdxConn
+	ready chan struct{} // 进入 StateReady 时关闭
+
+	done      chan struct{} // Close 时关闭
+	closeOnce sync.Once
+	wg        sync.WaitGroup
 }
 
-func (t *TdxHq) SecurityCount(req TDXSecurityCountRequest) TDXSecurityCountResponse {
-	msg, _ := t.Write(NewTDXSecurityCountMessage(req))
-	return msg.(*TDXSecurityCountMessage).TDXSecurityCountResponse
+func (t *TdxHq) SecurityCount(ctx context.Context, req TDXSecurityCountRequest) (TDXSecurityCountResponse, error) {
+	msg, err := t.Write(ctx, NewTDXSecurityCountMessage(req))
+	if err != nil {
+		return TDXSecurityCountResponse{}, err
+	}
+	sub, ok := msg.(*TDXSecurityCountMessage)
+	if !ok {
+		return TDXSecurityCountResponse{}, unexpectedMessage(KMSG_SECURITYCOUNT, msg)
+	}
+	return sub.TDXSecurityCountResponse, nil
 }
 
-func (t *TdxHq) BlockInfo(file string) TDXBlockInfoResponse {
+func (t *TdxHq) BlockInfo(ctx context.Context, file string) (TDXBlockInfoResponse, error) {
 	metareq := TDXBlockInfoMetaRequest{}
 	copy(metareq.BlockFile[:], []byte(file)[:])
-	meta, _ := t.Write(NewTDXBlockInfoMetaMessage(metareq))
+	meta, err := t.Write(ctx, NewTDXBlockInfoMetaMessage(metareq))
+	if err != nil {
+		return TDXBlockInfoResponse{}, err
+	}
 
-	sub := meta.(*TDXBlockInfoMetaMessage)
+	sub, ok := meta.(*TDXBlockInfoMetaMessage)
+	if !ok {
+		return TDXBlockInfoResponse{}, unexpectedMessage(KMSG_BLOCKINFOMETA, meta)
+	}
 	chunk := sub.Size / BLOCK_CHUNKS_SIZE
 	if sub.Size%BLOCK_CHUNKS_SIZE != 0 {
 		chunk += 1
 	}
 
 	if chunk <= 0 {
-		return TDXBlockInfoResponse{}
+		return TDXBlockInfoResponse{}, nil
 	}
 	BlockFileContent := new(bytes.Buffer)
 	for i := uint32(0); i < chunk; i++ {
@@ -68,184 +113,369 @@ func (t *TdxHq) BlockInfo(file string) TDXBlockInfoResponse {
 		req.Size = sub.Size
 		req.Start = i * BLOCK_CHUNKS_SIZE
 		copy(req.BlockFile[:], []byte(file)[:])
-		msg, _ := t.Write(NewTDXBlockInfoMessage(req))
-		BlockFileContent.Write(msg.(*TDXBlockInfoMessage).FileContent)
-	}
-
-	// http://blog.csdn.net/Metal1/article/details/44352639
-	pos := 384
-	resp := TDXBlockInfoResponse{}
-	binary.Read(bytes.NewBuffer(BlockFileContent.Bytes()[pos:pos+2]), binary.LittleEndian, &resp.BlockNum)
-	pos += 2
-	for index := uint16(0); index < resp.BlockNum; index++ {
-		b := BlockInfo{}
-		enc := mahonia.NewDecoder("gbk")
-		b.Blockname = enc.ConvertString(string(BlockFileContent.Bytes()[pos : pos+9]))
-		pos += 9
-		binary.Read(bytes.NewBuffer(BlockFileContent.Bytes()[pos:pos+2]), binary.LittleEndian, &b.Stockcount)
-		pos += 2
-		binary.Read(bytes.NewBuffer(BlockFileContent.Bytes()[pos:pos+2]), binary.LittleEndian, &b.Blocktype)
-		pos += 2
-
-		block_begin_pos := pos
-		for codeindex := uint16(0); codeindex < b.Stockcount; codeindex++ {
-			code := string(BlockFileContent.Bytes()[pos : pos+7])
-			b.Codelist = append(b.Codelist, code)
-			pos += 7
+		msg, err := t.Write(ctx, NewTDXBlockInfoMessage(req))
+		if err != nil {
+			return TDXBlockInfoResponse{}, err
+		}
+		content, ok := msg.(*TDXBlockInfoMessage)
+		if !ok {
+			return TDXBlockInfoResponse{}, unexpectedMessage(KMSG_BLOCKINFO, msg)
 		}
-		resp.Block = append(resp.Block, b)
-		pos = block_begin_pos + 2800
+		BlockFileContent.Write(content.FileContent)
 	}
-	return resp
-}
 
-func (t *TdxHq) CompanyInfoCategory(req TDXCompanyInfoCategoryRequest) TDXCompanyInfoCategoryResponse {
-	msg, _ := t.Write(NewTDXCompanyInfoCategoryMessage(req))
-	return msg.(*TDXCompanyInfoCategoryMessage).TDXCompanyInfoCategoryResponse
+	return DecodeBlockFile(BlockFileContent.Bytes())
 }
 
-func (t *TdxHq) CompanyInfoContent(req TDXCompanyInfoContentRequest) TDXCompanyInfoContentResponse {
-	msg, _ := t.Write(NewTDXCompanyInfoContentMessage(req))
-	return msg.(*TDXCompanyInfoContentMessage).TDXCompanyInfoContentResponse
+func (t *TdxHq) CompanyInfoCategory(ctx context.Context, req TDXCompanyInfoCategoryRequest) (TDXCompanyInfoCategoryResponse, error) {
+	msg, err := t.Write(ctx, NewTDXCompanyInfoCategoryMessage(req))
+	if err != nil {
+		return TDXCompanyInfoCategoryResponse{}, err
+	}
+	sub, ok := msg.(*TDXCompanyInfoCategoryMessage)
+	if !ok {
+		return TDXCompanyInfoCategoryResponse{}, unexpectedMessage(KMSG_COMPANYCATEGORY, msg)
+	}
+	return sub.TDXCompanyInfoCategoryResponse, nil
 }
 
-func (t *TdxHq) FinanceInfo(req TDXFinanceInfoRequest) TDXFinanceInfoResponse {
-	msg, _ := t.Write(NewTDXFinanceInfoMessage(req))
-	return msg.(*TDXFinanceInfoMessage).TDXFinanceInfoResponse
+func (t *TdxHq) CompanyInfoContent(ctx context.Context, req TDXCompanyInfoContentRequest) (TDXCompanyInfoContentResponse, error) {
+	msg, err := t.Write(ctx, NewTDXCompanyInfoContentMessage(req))
+	if err != nil {
+		return TDXCompanyInfoContentResponse{}, err
+	}
+	sub, ok := msg.(*TDXCompanyInfoContentMessage)
+	if !ok {
+		return TDXCompanyInfoContentResponse{}, unexpectedMessage(KMSG_COMPANYCONTENT, msg)
+	}
+	return sub.TDXCompanyInfoContentResponse, nil
 }
 
-func (t *TdxHq) HistoryMinuteTimeDate(req TDXHistoryMinuteTimeDateRequest) TDXHistoryMinuteTimeDateResponse {
-	msg, _ := t.Write(NewTDXHistoryMinuteTimeDateMessage(req))
-	return msg.(*TDXHistoryMinuteTimeDateMessage).TDXHistoryMinuteTimeDateResponse
+func (t *TdxHq) FinanceInfo(ctx context.Context, req TDXFinanceInfoRequest) (TDXFinanceInfoResponse, error) {
+	msg, err := t.Write(ctx, NewTDXFinanceInfoMessage(req))
+	if err != nil {
+		return TDXFinanceInfoResponse{}, err
+	}
+	sub, ok := msg.(*TDXFinanceInfoMessage)
+	if !ok {
+		return TDXFinanceInfoResponse{}, unexpectedMessage(KMSG_FINANCEINFO, msg)
+	}
+	return sub.TDXFinanceInfoResponse, nil
 }
 
-func (t *TdxHq) HistoryTransactionData(req TDXHistoryTransactionDataRequest) TDXHistoryTransactionDataResponse {
-	msg, _ := t.Write(NewTDXHistoryTransactionDataMessage(req))
-	return msg.(*TDXHistoryTransactionDataMessage).TDXHistoryTransactionDataResponse
+func (t *TdxHq) HistoryMinuteTimeDate(ctx context.Context, req TDXHistoryMinuteTimeDateRequest) (TDXHistoryMinuteTimeDateResponse, error) {
+	msg, err := t.Write(ctx, NewTDXHistoryMinuteTimeDateMessage(req))
+	if err != nil {
+		return TDXHistoryMinuteTimeDateResponse{}, err
+	}
+	sub, ok := msg.(*TDXHistoryMinuteTimeDateMessage)
+	if !ok {
+		return TDXHistoryMinuteTimeDateResponse{}, unexpectedMessage(KMSG_HISTORYMINUTETIMEDATE, msg)
+	}
+	return sub.TDXHistoryMinuteTimeDateResponse, nil
 }
 
-func (t *TdxHq) IndexBars(req TDXIndexBarsRequest) TDXIndexBarsResponse {
-	msg, _ := t.Write(NewTDXIndexBarsMessage(req))
-	return msg.(*TDXIndexBarsMessage).TDXIndexBarsResponse
+func (t *TdxHq) HistoryTransactionData(ctx context.Context, req TDXHistoryTransactionDataRequest) (TDXHistoryTransactionDataResponse, error) {
+	msg, err := t.Write(ctx, NewTDXHistoryTransactionDataMessage(req))
+	if err != nil {
+		return TDXHistoryTransactionDataResponse{}, err
+	}
+	sub, ok := msg.(*TDXHistoryTransactionDataMessage)
+	if !ok {
+		return TDXHistoryTransactionDataResponse{}, unexpectedMessage(KMSG_HISTORYTRANSACTIONDATA, msg)
+	}
+	return sub.TDXHistoryTransactionDataResponse, nil
 }
 
-func (t *TdxHq) MinuteTimeData(req TDXMinuteTimeDataRequest) TDXMinuteTimeDataResponse {
-	msg, _ := t.Write(NewTDXMinuteTimeDataMessage(req))
-	return msg.(*TDXMinuteTimeDataMessage).TDXMinuteTimeDataResponse
+func (t *TdxHq) IndexBars(ctx context.Context, req TDXIndexBarsRequest) (TDXIndexBarsResponse, error) {
+	msg, err := t.Write(ctx, NewTDXIndexBarsMessage(req))
+	if err != nil {
+		return TDXIndexBarsResponse{}, err
+	}
+	sub, ok := msg.(*TDXIndexBarsMessage)
+	if !ok {
+		return TDXIndexBarsResponse{}, unexpectedMessage(KMSG_INDEXBARS, msg)
+	}
+	return sub.TDXIndexBarsResponse, nil
 }
 
-func (t *TdxHq) SecurityList(req TDXSecurityListRequest) TDXSecurityListResponse {
-	msg, _ := t.Write(NewTDXSecurityListMessage(req))
-	return msg.(*TDXSecurityListMessage).TDXSecurityListResponse
+func (t *TdxHq) SecurityBars(ctx context.Context, req TDXSecurityBarsRequest) (TDXSecurityBarsResponse, error) {
+	msg, err := t.Write(ctx, NewTDXSecurityBarsMessage(req))
+	if err != nil {
+		return TDXSecurityBarsResponse{}, err
+	}
+	sub, ok := msg.(*TDXSecurityBarsMessage)
+	if !ok {
+		return TDXSecurityBarsResponse{}, unexpectedMessage(KMSG_INDEXBARS, msg)
+	}
+	return sub.TDXSecurityBarsResponse, nil
 }
 
-func (t *TdxHq) SecurityQuotes(req TDXSecurityQuotesRequest) TDXSecurityQuotesResponse {
-	msg, _ := t.Write(NewTDXSecurityQuotesMessage(req))
-	return msg.(*TDXSecurityQuotesMessage).TDXSecurityQuotesResponse
+func (t *TdxHq) MinuteTimeData(ctx context.Context, req TDXMinuteTimeDataRequest) (TDXMinuteTimeDataResponse, error) {
+	msg, err := t.Write(ctx, NewTDXMinuteTimeDataMessage(req))
+	if err != nil {
+		return TDXMinuteTimeDataResponse{}, err
+	}
+	sub, ok := msg.(*TDXMinuteTimeDataMessage)
+	if !ok {
+		return TDXMinuteTimeDataResponse{}, unexpectedMessage(KMSG_MINUTETIMEDATA, msg)
+	}
+	return sub.TDXMinuteTimeDataResponse, nil
 }
 
-func (t *TdxHq) TransactionData(req TDXTransactionDataRequest) TDXTransactionDataResponse {
-	msg, _ := t.Write(NewTDXTransactionDataMessage(req))
-	return msg.(*TDXTransactionDataMessage).TDXTransactionDataResponse
+func (t *TdxHq) SecurityList(ctx context.Context, req TDXSecurityListRequest) (TDXSecurityListResponse, error) {
+	msg, err := t.Write(ctx, NewTDXSecurityListMessage(req))
+	if err != nil {
+		return TDXSecurityListResponse{}, err
+	}
+	sub, ok := msg.(*TDXSecurityListMessage)
+	if !ok {
+		return TDXSecurityListResponse{}, unexpectedMessage(KMSG_SECURITYLIST, msg)
+	}
+	return sub.TDXSecurityListResponse, nil
 }
 
-func (t *TdxHq) XdxrInfo(req TDXXdxrInfoRequest) TDXXdxrInfoResponse {
-	msg, _ := t.Write(NewTDXXdxrInfoMessage(req))
-	return msg.(*TDXXdxrInfoMessage).TDXXdxrInfoResponse
+func (t *TdxHq) SecurityQuotes(ctx context.Context, req TDXSecurityQuotesRequest) (TDXSecurityQuotesResponse, error) {
+	msg, err := t.Write(ctx, NewTDXSecurityQuotesMessage(req))
+	if err != nil {
+		return TDXSecurityQuotesResponse{}, err
+	}
+	sub, ok := msg.(*TDXSecurityQuotesMessage)
+	if !ok {
+		return TDXSecurityQuotesResponse{}, unexpectedMessage(KMSG_SECURITYQUOTES, msg)
+	}
+	return sub.TDXSecurityQuotesResponse, nil
 }
 
-func (t *TdxHq) start() {
-	c, err := net.Dial("tcp", "47.116.105.28:7709")
+func (t *TdxHq) TransactionData(ctx context.Context, req TDXTransactionDataRequest) (TDXTransactionDataResponse, error) {
+	msg, err := t.Write(ctx, NewTDXTransactionDataMessage(req))
 	if err != nil {
-		logger.Fatalln(err)
-		return
+		return TDXTransactionDataResponse{}, err
 	}
+	sub, ok := msg.(*TDXTransactionDataMessage)
+	if !ok {
+		return TDXTransactionDataResponse{}, unexpectedMessage(KMSG_TRANSACTIONDATA, msg)
+	}
+	return sub.TDXTransactionDataResponse, nil
+}
 
-	t.once = &sync.Once{}
-	t.wg = &sync.WaitGroup{}
-	t.sending = make(chan bool, 1)
-	t.complete = make(chan bool, 1)
-	t.rawConn = c
-
-	logger.Infoln("on connect")
-	t.Write(NewCMD1Message())
-	t.Write(NewCMD2Message())
+func (t *TdxHq) XdxrInfo(ctx context.Context, req TDXXdxrInfoRequest) (TDXXdxrInfoResponse, error) {
+	msg, err := t.Write(ctx, NewTDXXdxrInfoMessage(req))
+	if err != nil {
+		return TDXXdxrInfoResponse{}, err
+	}
+	sub, ok := msg.(*TDXXdxrInfoMessage)
+	if !ok {
+		return TDXXdxrInfoResponse{}, unexpectedMessage(KMSG_XDXRINFO, msg)
+	}
+	return sub.TDXXdxrInfoResponse, nil
+}
 
-	t.complete <- true
+// start 依次尝试地址列表建立首个连接, 成功后启动维护协程
+func (t *TdxHq) start() error {
+	var err error
+	for i, addr := range t.opts.Addrs {
+		if err = t.connect(addr); err == nil {
+			t.next = i + 1
+			break
+		}
+		logger.Warnf("connect %s failed: %v\n", addr, err)
+	}
+	if err != nil {
+		t.setState(StateClosed, "")
+		return fmt.Errorf("connect %v: %w", t.opts.Addrs, err)
+	}
 
-	t.HeartBeatTimer = time.NewTicker(time.Second)
 	t.wg.Add(1)
-	go t.HeartBeatCheck()
+	go t.run()
+	return nil
 }
 
-func (t *TdxHq) Write(message Message) (Message, error) {
-	defer func() {
-		if p := recover(); p != nil {
-			logger.Errorf("panics: %v\n", p)
-			t.ReStart()
+// connect 连接 addr 并完成登录握手, 成功后进入 StateReady
+func (t *TdxHq) connect(addr string) error {
+	t.setState(StateConnecting, addr)
+	raw, err := t.opts.dial(addr)
+	if err != nil {
+		return err
+	}
+	conn := newTdxConn(addr, raw, t.tdxcodec, func() {
+		t.SetHeartBeat(time.Now().UnixNano())
+	})
+
+	t.setState(StateHandshaking, addr)
+	logger.Infoln("on connect", addr)
+	ctx, cancel := context.WithTimeout(context.Background(), t.opts.ConnectTimeout)
+	defer cancel()
+	for _, msg := range t.handshake() {
+		if err = conn.roundTrip(ctx, msg); err != nil {
+			break
 		}
-	}()
+	}
+	if err != nil {
+		conn.Close()
+		return err
+	}
 
-	t.sending <- true
-	pkt, err := t.tdxcodec.Encode(message)
-	if _, err = t.rawConn.Write(pkt); err != nil {
-		return nil, err
+	t.mu.Lock()
+	if t.state == StateClosed {
+		t.mu.Unlock()
+		conn.Close()
+		return ErrClientClosed
 	}
-	<-t.sending
-	return t.Decode()
+	t.conn = conn
+	t.mu.Unlock()
+	t.SetHeartBeat(time.Now().UnixNano())
+	t.setState(StateReady, addr)
+	return nil
 }
 
-func (t *TdxHq) Decode() (Message, error) {
-	msg, err := t.tdxcodec.Decode(t.rawConn)
-	if err != nil {
-		logger.Errorf("error decoding message %v\n", err)
-		if _, ok := err.(ErrUndefined); ok {
-			t.SetHeartBeat(time.Now().UnixNano())
-		}
-		return nil, err
+// setState 切换状态并通知 Options.OnStateChange, 已关闭后不再切换
+func (t *TdxHq) setState(state ConnState, addr string) {
+	t.mu.Lock()
+	from := t.state
+	if from == state || from == StateClosed {
+		t.mu.Unlock()
+		return
 	}
-	t.SetHeartBeat(time.Now().UnixNano())
-	return msg, err
+	t.state = state
+	if state == StateReady {
+		close(t.ready)
+	} else if from == StateReady {
+		t.ready = make(chan struct{})
+	}
+	t.mu.Unlock()
+
+	logger.Debugf("conn state %v -> %v %s\n", from, state, addr)
+	if t.opts.OnStateChange != nil {
+		t.opts.OnStateChange(from, state, addr)
+	}
+}
+
+// State 当前连接状态
+func (t *TdxHq) State() ConnState {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.state
 }
 
-func (t *TdxHq) HeartBeatCheck() {
-	defer func() {
-		if p := recover(); p != nil {
-			logger.Errorf("panics: %v\n", p)
+// run 维护连接: 连接可用时定时发送心跳, 断开后按指数退避依次重连下一个地址, 直到 Close
+func (t *TdxHq) run() {
+	defer t.wg.Done()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		if !t.keepalive(ticker) {
+			return
 		}
-		t.wg.Done()
-		logger.Debugln("HeartBeat go-routine exited")
-		t.ReStart()
-	}()
+		for attempt := 1; ; attempt++ {
+			t.setState(StateBackingOff, "")
+			select {
+			case <-t.done:
+				return
+			case <-time.After(backoff(attempt, t.opts.BackoffMin, t.opts.BackoffMax)):
+			}
+			addr := t.opts.Addrs[t.next%len(t.opts.Addrs)]
+			t.next++
+			err := t.connect(addr)
+			if err == nil {
+				break
+			}
+			if err == ErrClientClosed {
+				return
+			}
+			logger.Warnf("reconnect %s failed: %v\n", addr, err)
+		}
+	}
+}
+
+// keepalive 在连接断开前维持心跳, 连接断开返回 true, Close 返回 false
+func (t *TdxHq) keepalive(ticker *time.Ticker) bool {
+	conn := t.current()
 	for {
 		select {
-		case <-t.HeartBeatTimer.C:
-			if (((time.Now().UnixNano() - t.HeartBeat()) / 1000000000) >= DEFAULT_HEARTBEAT_INTERVAL) {
-				t.Write(NewTDXSecurityCountMessage(TDXSecurityCountRequest{rand.Int31n(2)}))
+		case <-t.done:
+			return false
+		case <-conn.closed:
+			logger.Warnf("conn %s lost: %v\n", conn.addr, conn.err)
+			return true
+		case <-ticker.C:
+			if ((time.Now().UnixNano() - t.HeartBeat()) / 1000000000) >= DEFAULT_HEARTBEAT_INTERVAL {
+				ctx, cancel := context.WithTimeout(context.Background(), t.opts.ConnectTimeout)
+				err := conn.roundTrip(ctx, t.heartbeat())
+				cancel()
+				if IsRetryable(err) {
+					conn.Close()
+				}
 			}
 		}
 	}
 }
 
-func (t *TdxHq) SetHeartBeat(heart int64) {
-	t.heart = heart
+func (t *TdxHq) current() *tdxConn {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.conn
 }
 
-func (t *TdxHq) HeartBeat() int64 {
-	heart := t.heart
-	return heart
+// Addr 当前连接的服务器地址
+func (t *TdxHq) Addr() string {
+	if conn := t.current(); conn != nil {
+		return conn.addr
+	}
+	return ""
+}
+
+// Write 发送请求并把响应解码到 message 本身. 多个协程可以同时在同一连接上发出请求,
+// 响应按 SeqID 匹配; 正在重连时等待连接可用. ctx 没有截止时间时使用 Options.RequestTimeout.
+func (t *TdxHq) Write(ctx context.Context, message Message) (Message, error) {
+	if _, ok := ctx.Deadline(); !ok && t.opts.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.opts.RequestTimeout)
+		defer cancel()
+	}
+	for {
+		t.mu.RLock()
+		state, conn, ready := t.state, t.conn, t.ready
+		t.mu.RUnlock()
+		switch state {
+		case StateClosed:
+			return nil, ErrClientClosed
+		case StateReady:
+			if err := conn.roundTrip(ctx, message); err != nil {
+				return nil, err
+			}
+			return message, nil
+		}
+		select {
+		case <-ready:
+		case <-t.done:
+		case <-ctx.Done():
+			return nil, connError(ctx, ctx.Err())
+		}
+	}
+}
+
+func (t *TdxHq) SetHeartBeat(heart int64) {
+	atomic.StoreInt64(&t.heart, heart)
 }
 
-func (t *TdxHq) ReStart() {
-	t.Release()
-	t.start()
+func (t *TdxHq) HeartBeat() int64 {
+	return atomic.LoadInt64(&t.heart)
 }
 
-func (t *TdxHq) Release() {
-	t.once.Do(func() {
-		logger.Infof("conn close gracefully, <%v -> %v>\n", t.rawConn.LocalAddr(), t.rawConn.RemoteAddr())
-		t.rawConn.Close()
+// Close 关闭连接并停止心跳和重连, 等待维护协程退出
+func (t *TdxHq) Close() error {
+	var err error
+	t.closeOnce.Do(func() {
+		t.setState(StateClosed, t.Addr())
+		close(t.done)
+		if conn := t.current(); conn != nil {
+			err = conn.Close()
+		}
+		t.wg.Wait()
 	})
+	return err
 }
 
 func init() {
@@ -0,0 +1,198 @@
+package gotdx
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	. "gotdx/imsg"
+	"testing"
+	"time"
+)
+
+func newMockHq(t *testing.T) (*TdxHq, *mockServer) {
+	srv := newMockServer(t)
+	hq, err := NewTdxHqWithOptions(WithAddrs(srv.Addr()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { hq.Close() })
+	return hq, srv
+}
+
+func TestTdxHq_SecurityCountMock(t *testing.T) {
+	hq, srv := newMockHq(t)
+	srv.Handle(KMSG_SECURITYCOUNT, func([]byte) []byte {
+		b := make([]byte, 2)
+		binary.LittleEndian.PutUint16(b, 1234)
+		return b
+	})
+
+	rsp, err := hq.SecurityCount(context.Background(), TDXSecurityCountRequest{Market: MARKET_SH})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rsp.Count != 1234 {
+		t.Errorf("Count = %d, want 1234", rsp.Count)
+	}
+}
+
+func TestTdxHq_Timeout(t *testing.T) {
+	hq, _ := newMockHq(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := hq.SecurityCount(ctx, TDXSecurityCountRequest{Market: MARKET_SH})
+	var timeout ErrTimeout
+	if !errors.As(err, &timeout) {
+		t.Fatalf("err = %v, want ErrTimeout", err)
+	}
+	if !IsRetryable(err) {
+		t.Error("timeout should be retryable")
+	}
+}
+
+func TestTdxHq_ConnReset(t *testing.T) {
+	hq, srv := newMockHq(t)
+	srv.Handle(KMSG_SECURITYCOUNT, func([]byte) []byte { return nil })
+
+	_, err := hq.SecurityCount(context.Background(), TDXSecurityCountRequest{Market: MARKET_SH})
+	var reset ErrConnReset
+	if !errors.As(err, &reset) {
+		t.Fatalf("err = %v, want ErrConnReset", err)
+	}
+}
+
+func TestTdxHq_DecodeError(t *testing.T) {
+	hq, srv := newMockHq(t)
+	// 声明 3 条行情但没有数据
+	srv.Handle(KMSG_SECURITYQUOTES, func([]byte) []byte { return []byte{0, 0, 3, 0} })
+
+	_, err := hq.SecurityQuotes(context.Background(), TDXSecurityQuotesRequest{})
+	var decode ErrDecode
+	if !errors.As(err, &decode) {
+		t.Fatalf("err = %v, want ErrDecode", err)
+	}
+	if IsRetryable(err) {
+		t.Error("decode failure should not be retryable")
+	}
+}
+
+func TestTdxHq_UnexpectedMessage(t *testing.T) {
+	hq, srv := newMockHq(t)
+	srv.HandleAs(KMSG_SECURITYCOUNT, KMSG_CMD1, func([]byte) []byte { return []byte{0} })
+
+	_, err := hq.SecurityCount(context.Background(), TDXSecurityCountRequest{Market: MARKET_SH})
+	var unexpected ErrUnexpectedMessage
+	if !errors.As(err, &unexpected) {
+		t.Fatalf("err = %v, want ErrUnexpectedMessage", err)
+	}
+	if unexpected.Want != KMSG_SECURITYCOUNT || unexpected.Got != KMSG_CMD1 {
+		t.Errorf("got %+v", unexpected)
+	}
+}
+
+func TestTdxHq_RepeatedCalls(t *testing.T) {
+	hq, srv := newMockHq(t)
+	srv.Handle(KMSG_SECURITYLIST, func([]byte) []byte {
+		b := []byte{2, 0}
+		for _, code := range []string{"600000", "600004"} {
+			ele := make([]byte, 29)
+			copy(ele, code)
+			b = append(b, ele...)
+		}
+		return b
+	})
+
+	for i := 0; i < 3; i++ {
+		rsp, err := hq.SecurityList(context.Background(), TDXSecurityListRequest{Market: MARKET_SH})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rsp.List) != 2 || rsp.List[0].Code != "600000" || rsp.List[1].Code != "600004" {
+			t.Fatalf("call %d: got %+v", i, rsp.List)
+		}
+	}
+}
+
+func TestTdxHq_ConcurrentCalls(t *testing.T) {
+	hq, srv := newMockHq(t)
+	// 返回 1000 + 请求的市场编号
+	srv.Handle(KMSG_SECURITYCOUNT, func(req []byte) []byte {
+		b := make([]byte, 2)
+		binary.LittleEndian.PutUint16(b, 1000+binary.LittleEndian.Uint16(req))
+		return b
+	})
+
+	errs := make(chan error, 16)
+	for i := 0; i < cap(errs); i++ {
+		go func(market int32) {
+			rsp, err := hq.SecurityCount(context.Background(), TDXSecurityCountRequest{Market: market})
+			if err == nil && rsp.Count != uint16(1000+market) {
+				err = errors.New("response matched to the wrong request")
+			}
+			errs <- err
+		}(int32(i))
+	}
+	for i := 0; i < cap(errs); i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestTdxHq_Pipelining(t *testing.T) {
+	hq, srv := newMockHq(t)
+	// 市场编号越小响应越慢, 响应顺序与请求顺序相反
+	srv.Handle(KMSG_SECURITYCOUNT, func(req []byte) []byte {
+		market := binary.LittleEndian.Uint16(req)
+		time.Sleep(time.Duration(20-market) * 5 * time.Millisecond)
+		b := make([]byte, 2)
+		binary.LittleEndian.PutUint16(b, 1000+market)
+		return b
+	})
+
+	start := time.Now()
+	errs := make(chan error, 16)
+	for i := 0; i < cap(errs); i++ {
+		go func(market int32) {
+			rsp, err := hq.SecurityCount(context.Background(), TDXSecurityCountRequest{Market: market})
+			if err == nil && rsp.Count != uint16(1000+market) {
+				err = errors.New("response matched to the wrong request")
+			}
+			errs <- err
+		}(int32(i))
+	}
+	for i := 0; i < cap(errs); i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+	// 串行执行至少需要 16 个请求耗时之和
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("requests were not pipelined, took %v", elapsed)
+	}
+}
+
+func TestTdxHq_RequestTimeoutKeepsConn(t *testing.T) {
+	srv := newMockServer(t)
+	hq, err := NewTdxHqWithOptions(WithAddrs(srv.Addr()), WithRequestTimeout(100*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 市场 0 永不返回, 其它市场正常返回
+	srv.Handle(KMSG_SECURITYCOUNT, func(req []byte) []byte {
+		if binary.LittleEndian.Uint16(req) == 0 {
+			time.Sleep(time.Second)
+		}
+		return []byte{1, 0}
+	})
+
+	_, err = hq.SecurityCount(context.Background(), TDXSecurityCountRequest{Market: MARKET_SZ})
+	var timeout ErrTimeout
+	if !errors.As(err, &timeout) {
+		t.Fatalf("err = %v, want ErrTimeout", err)
+	}
+	if _, err = hq.SecurityCount(context.Background(), TDXSecurityCountRequest{Market: MARKET_SH}); err != nil {
+		t.Fatalf("connection unusable after a request timeout: %v", err)
+	}
+}
@@ -1,99 +1,158 @@
 package gotdx
 
 import (
+	"context"
 	"fmt"
 	. "gotdx/imsg"
 	"testing"
+	"time"
 )
 
-var tdx ITdxHq
+var (
+	tdx    ITdxHq
+	tdxErr error
+	ctx    = context.Background()
+)
+
+// connectHq 连接行情服务器, 连接失败时跳过需要网络的测试
+func connectHq(t *testing.T) {
+	if tdx == nil && tdxErr == nil {
+		var hq *TdxHq
+		if hq, tdxErr = NewTdxHqWithOptions(); tdxErr == nil {
+			tdx = hq
+		}
+	}
+	if tdxErr != nil {
+		t.Skip(tdxErr)
+	}
+}
 
 func TestTdxHq_SecurityCount(t *testing.T) {
-	tdx = NewTdxHq()
-	tdx.BlockInfo(BLOCK_DEFAULT)
-	tdx.BlockInfo(BLOCK_GN)
-	tdx.BlockInfo(BLOCK_FG)
-	tdx.BlockInfo(BLOCK_ZS)
+	connectHq(t)
+	if _, err := tdx.BlockInfo(ctx, BLOCK_DEFAULT); err != nil {
+		t.Error(err)
+	}
+	if _, err := tdx.BlockInfo(ctx, BLOCK_GN); err != nil {
+		t.Error(err)
+	}
+	if _, err := tdx.BlockInfo(ctx, BLOCK_FG); err != nil {
+		t.Error(err)
+	}
+	if _, err := tdx.BlockInfo(ctx, BLOCK_ZS); err != nil {
+		t.Error(err)
+	}
 }
 
 func TestTdxHq_CompanyInfoCategory(t *testing.T) {
-	cic := TDXCompanyInfoCategoryRequest{}
-	cic.Market = MARKET_SH
-	copy(cic.Code[:], "600000")
-	rsp := tdx.CompanyInfoCategory(cic)
+	connectHq(t)
+	rsp, err := tdx.CompanyInfoCategory(ctx, NewTDXCompanyInfoCategoryRequest(MARKET_SH, "600000"))
+	if err != nil {
+		t.Fatal(err)
+	}
 	for _, v := range rsp.List {
-		req := TDXCompanyInfoContentRequest{}
-		req.Start = v.Start
-		req.Length = v.Interval
-		req.Market = MARKET_SH
-		copy(req.Code[:], "600000")
-		copy(req.FileName[:], []byte(v.FileName)[:])
-		tdx.CompanyInfoContent(req)
+		req := NewTDXCompanyInfoContentRequest(MARKET_SH, "600000", v)
+		if _, err := tdx.CompanyInfoContent(ctx, req); err != nil {
+			t.Error(err)
+		}
 	}
 }
 
 func TestTdxHq_FinanceInfo(t *testing.T) {
+	connectHq(t)
 	fi := TDXFinanceInfoRequest{}
 	fi.Market = MARKET_SH
 	copy(fi.Code[:], "600004")
-	tdx.FinanceInfo(fi)
+	if _, err := tdx.FinanceInfo(ctx, fi); err != nil {
+		t.Error(err)
+	}
 }
 
 func TestTdxHq_HistoryMinuteTimeDate(t *testing.T) {
+	connectHq(t)
 	hmtd := TDXHistoryMinuteTimeDateRequest{}
 	hmtd.Market = MARKET_SH
 	hmtd.Date = 20200826
 	copy(hmtd.Code[:], "600000")
-	tdx.HistoryMinuteTimeDate(hmtd)
+	if _, err := tdx.HistoryMinuteTimeDate(ctx, hmtd); err != nil {
+		t.Error(err)
+	}
 }
 
 func TestTdxHq_HistoryTransactionData(t *testing.T) {
+	connectHq(t)
 	htd := TDXHistoryTransactionDataRequest{}
 	htd.Market = MARKET_SH
 	copy(htd.Code[:], "600000")
 	htd.Date = 20200818
 	htd.Start = 0
 	htd.Count = 100
-	tdx.HistoryTransactionData(htd)
+	if _, err := tdx.HistoryTransactionData(ctx, htd); err != nil {
+		t.Error(err)
+	}
 }
 
 func TestTdxHq_IndexBars(t *testing.T) {
+	connectHq(t)
 	ib := NewTDXIndexBarsRequest(MARKET_SH, "600000", KLINE_TYPE_1MIN, 0, 20)
-	tdx.IndexBars(ib)
+	if _, err := tdx.IndexBars(ctx, ib); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTdxHq_SecurityBars(t *testing.T) {
+	connectHq(t)
+	sb := NewTDXSecurityBarsRequest(MARKET_SH, "600000", KLINE_TYPE_DAILY, 0, 20)
+	if _, err := tdx.SecurityBars(ctx, sb); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBarsRange_Network(t *testing.T) {
+	connectHq(t)
+	from := time.Now().AddDate(-5, 0, 0)
+	if _, err := BarsRange(ctx, tdx, MARKET_SH, "600000", KLINE_TYPE_DAILY, from, time.Time{}); err != nil {
+		t.Error(err)
+	}
 }
 
 func TestTdxHq_MinuteTimeData(t *testing.T) {
+	connectHq(t)
 	mtd := NewTDXMinuteTimeDataRequest(MARKET_SH, "600000")
-	tdx.MinuteTimeData(mtd)
+	if _, err := tdx.MinuteTimeData(ctx, mtd); err != nil {
+		t.Error(err)
+	}
 }
 
 func TestTdxHq_SecurityList(t *testing.T) {
-	var num uint16 = 0
-	sl := TDXSecurityListRequest{MARKET_SH, 0}
-	for {
-		rsp := tdx.SecurityList(sl)
-		if rsp.Num%1000 == 0 {
-			num += rsp.Num
-			sl.Start = num
-		} else {
-			break
+	connectHq(t)
+	for _, market := range []uint8{MARKET_SH, MARKET_SZ} {
+		count, err := tdx.SecurityCount(ctx, TDXSecurityCountRequest{Market: int32(market)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		list, err := SecurityListAll(ctx, tdx, market)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != int(count.Count) {
+			t.Errorf("market %d: got %d securities, want %d", market, len(list), count.Count)
 		}
 	}
+}
 
-	num = 0
-	sl.Market = MARKET_SZ
-	for {
-		rsp := tdx.SecurityList(sl)
-		if rsp.Num%1000 == 0 {
-			num += rsp.Num
-			sl.Start = num
-		} else {
-			break
-		}
+func TestLoadSecurityUniverse_Network(t *testing.T) {
+	connectHq(t)
+	u, err := LoadSecurityUniverse(ctx, tdx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, ok := u.Security(MARKET_SH, "600000"); !ok || s.Type != SecurityAShare {
+		t.Errorf("Security(600000) = %+v, %v", s, ok)
 	}
 }
 
 func TestTdxHq_SecurityQuotes(t *testing.T) {
+	connectHq(t)
 	sq := TDXSecurityQuotesRequest{}
 
 	reqele := ReqSecurityQuotesElement{}
@@ -105,22 +164,54 @@ func TestTdxHq_SecurityQuotes(t *testing.T) {
 	copy(reqele.Code[:], "600006")
 	sq.List = append(sq.List, reqele)
 
-	rsp := tdx.SecurityQuotes(sq)
+	rsp, err := tdx.SecurityQuotes(ctx, sq)
+	if err != nil {
+		t.Fatal(err)
+	}
 	fmt.Print(rsp)
 }
 
 func TestTdxHq_TransactionData(t *testing.T) {
+	connectHq(t)
 	tt := TDXTransactionDataRequest{}
 	tt.Market = MARKET_SH
 	copy(tt.Code[:], "600000")
 	tt.Start = 0
 	tt.Count = 30
-	tdx.TransactionData(tt)
+	if _, err := tdx.TransactionData(ctx, tt); err != nil {
+		t.Error(err)
+	}
 }
 
 func TestTdxHq_XdxrInfo(t *testing.T) {
+	connectHq(t)
 	xi := TDXXdxrInfoRequest{}
 	xi.Market = MARKET_SH
 	copy(xi.Code[:], "600000")
-	tdx.XdxrInfo(xi)
+	if _, err := tdx.XdxrInfo(ctx, xi); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDayTransactions_Network(t *testing.T) {
+	connectHq(t)
+	date := time.Date(2020, 8, 18, 0, 0, 0, 0, ChinaLocation)
+	ticks, err := DayTransactions(ctx, tdx, MARKET_SH, "600000", date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ticks) == 0 {
+		t.Error("got no ticks")
+	}
+}
+
+func TestQuotesFor_Network(t *testing.T) {
+	connectHq(t)
+	quotes, err := QuotesFor(ctx, tdx, []string{"sh600000", "000001.SZ"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quotes) != 2 {
+		t.Errorf("got %d quotes, want 2", len(quotes))
+	}
 }
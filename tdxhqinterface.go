@@ -1,24 +1,41 @@
 package gotdx
 
-import . "gotdx/imsg"
+import (
+	"context"
+	. "gotdx/imsg"
+)
 
-//通达信行情接口
+//通达信行情接口, 所有请求受 ctx 控制, 出错时返回 ErrTimeout、ErrConnReset、ErrDecode 或 ErrUnexpectedMessage
 type ITdxHq interface {
-	SecurityCount(TDXSecurityCountRequest) TDXSecurityCountResponse
-	BlockInfo(string) TDXBlockInfoResponse
-	CompanyInfoCategory(TDXCompanyInfoCategoryRequest) TDXCompanyInfoCategoryResponse
-	CompanyInfoContent(TDXCompanyInfoContentRequest) TDXCompanyInfoContentResponse
-	FinanceInfo(TDXFinanceInfoRequest) TDXFinanceInfoResponse
-	HistoryMinuteTimeDate(TDXHistoryMinuteTimeDateRequest) TDXHistoryMinuteTimeDateResponse
-	HistoryTransactionData(TDXHistoryTransactionDataRequest) TDXHistoryTransactionDataResponse
-	IndexBars(TDXIndexBarsRequest) TDXIndexBarsResponse
-	MinuteTimeData(TDXMinuteTimeDataRequest) TDXMinuteTimeDataResponse
-	SecurityList(TDXSecurityListRequest) TDXSecurityListResponse
-	SecurityQuotes(TDXSecurityQuotesRequest) TDXSecurityQuotesResponse
-	TransactionData(TDXTransactionDataRequest) TDXTransactionDataResponse
-	XdxrInfo(TDXXdxrInfoRequest) TDXXdxrInfoResponse
+	SecurityCount(context.Context, TDXSecurityCountRequest) (TDXSecurityCountResponse, error)
+	BlockInfo(context.Context, string) (TDXBlockInfoResponse, error)
+	CompanyInfoCategory(context.Context, TDXCompanyInfoCategoryRequest) (TDXCompanyInfoCategoryResponse, error)
+	CompanyInfoContent(context.Context, TDXCompanyInfoContentRequest) (TDXCompanyInfoContentResponse, error)
+	FinanceInfo(context.Context, TDXFinanceInfoRequest) (TDXFinanceInfoResponse, error)
+	HistoryMinuteTimeDate(context.Context, TDXHistoryMinuteTimeDateRequest) (TDXHistoryMinuteTimeDateResponse, error)
+	HistoryTransactionData(context.Context, TDXHistoryTransactionDataRequest) (TDXHistoryTransactionDataResponse, error)
+	IndexBars(context.Context, TDXIndexBarsRequest) (TDXIndexBarsResponse, error)
+	// SecurityBars 股票、基金K线, 有意与 IndexBars 共用消息号 KMSG_INDEXBARS(0x52d, 与 pytdx 相同),
+	// 服务器按代码返回对应格式, 响应按请求的消息类型解码
+	SecurityBars(context.Context, TDXSecurityBarsRequest) (TDXSecurityBarsResponse, error)
+	MinuteTimeData(context.Context, TDXMinuteTimeDataRequest) (TDXMinuteTimeDataResponse, error)
+	SecurityList(context.Context, TDXSecurityListRequest) (TDXSecurityListResponse, error)
+	SecurityQuotes(context.Context, TDXSecurityQuotesRequest) (TDXSecurityQuotesResponse, error)
+	TransactionData(context.Context, TDXTransactionDataRequest) (TDXTransactionDataResponse, error)
+	XdxrInfo(context.Context, TDXXdxrInfoRequest) (TDXXdxrInfoResponse, error)
 }
 
-//通达信拓展行情接口
+//通达信拓展行情接口, 提供期货、港股、期权、基金等品种的行情
 type ITdxEXHq interface {
+	Markets(context.Context) (TDXExMarketsResponse, error)
+	InstrumentCount(context.Context) (TDXExInstrumentCountResponse, error)
+	InstrumentInfo(context.Context, TDXExInstrumentInfoRequest) (TDXExInstrumentInfoResponse, error)
+	InstrumentQuote(context.Context, TDXExInstrumentQuoteRequest) (TDXExInstrumentQuoteResponse, error)
+	InstrumentQuoteList(context.Context, TDXExInstrumentQuoteListRequest) (TDXExInstrumentQuoteListResponse, error)
+	InstrumentBars(context.Context, TDXExInstrumentBarsRequest) (TDXExInstrumentBarsResponse, error)
+	HistoryInstrumentBarsRange(context.Context, TDXExHistoryInstrumentBarsRangeRequest) (TDXExHistoryInstrumentBarsRangeResponse, error)
+	MinuteTimeData(context.Context, TDXExMinuteTimeDataRequest) (TDXExMinuteTimeDataResponse, error)
+	HistoryMinuteTimeData(context.Context, TDXExHistoryMinuteTimeDataRequest) (TDXExHistoryMinuteTimeDataResponse, error)
+	TransactionData(context.Context, TDXExTransactionDataRequest) (TDXExTransactionDataResponse, error)
+	HistoryTransactionData(context.Context, TDXExHistoryTransactionDataRequest) (TDXExHistoryTransactionDataResponse, error)
 }
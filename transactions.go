@@ -0,0 +1,169 @@
+package gotdx
+
+import (
+	"context"
+	"fmt"
+	. "gotdx/imsg"
+	"gotdx/security"
+	"math"
+	"time"
+)
+
+const TRANSACTION_VOLUME_TOLERANCE = 0.001 // 分笔成交量与日线成交量允许的相对误差
+
+// Tick 分笔成交
+type Tick struct {
+	Time      time.Time // 成交时间, 精确到分钟
+	Price     float64
+	Vol       int // 成交量(手)
+	Num       int // 成交笔数, 仅当日数据有
+	BuyOrSell int // 0 买, 1 卖, 2 集合竞价
+}
+
+// TransactionIterator 从最新的分笔开始每次向前取 MAX_TRANSACTION_COUNT 条, 例如
+//
+//	it := NewTransactionIterator(hq, MARKET_SH, "600000", time.Time{})
+//	for it.Next(ctx) {
+//		page := it.Page()
+//	}
+//	if err := it.Err(); err != nil {
+//	}
+type TransactionIterator struct {
+	hq     ITdxHq
+	market uint16
+	code   string
+	date   time.Time // 零值表示当日
+	start  int
+	done   bool
+	page   []Tick
+	err    error
+}
+
+// NewTransactionIterator 遍历 date 当天的分笔成交, date 为零值时取当日实时数据
+func NewTransactionIterator(hq ITdxHq, market uint16, code string, date time.Time) *TransactionIterator {
+	return &TransactionIterator{hq: hq, market: market, code: code, date: date}
+}
+
+// Next 取更早的一页, 没有更多数据或出错时返回 false
+func (it *TransactionIterator) Next(ctx context.Context) bool {
+	if it.done || it.err != nil || it.start > math.MaxUint16 {
+		return false
+	}
+	var list []TransactionElement
+	day := it.date
+	if day.IsZero() {
+		day = time.Now().In(ChinaLocation)
+		req := TDXTransactionDataRequest{Market: it.market, Start: uint16(it.start), Count: MAX_TRANSACTION_COUNT}
+		copy(req.Code[:], it.code)
+		rsp, err := it.hq.TransactionData(ctx, req)
+		it.err = err
+		list = rsp.List
+	} else {
+		req := TDXHistoryTransactionDataRequest{Date: yyyymmdd(day), Market: it.market, Start: uint16(it.start), Count: MAX_TRANSACTION_COUNT}
+		copy(req.Code[:], it.code)
+		rsp, err := it.hq.HistoryTransactionData(ctx, req)
+		it.err = err
+		list = rsp.List
+	}
+	if it.err != nil || len(list) == 0 {
+		it.done = true
+		return false
+	}
+
+	it.page = make([]Tick, 0, len(list))
+	for _, e := range list {
+		var h, m int
+		fmt.Sscanf(e.Time, "%d:%d", &h, &m)
+		it.page = append(it.page, Tick{
+			Time:      time.Date(day.Year(), day.Month(), day.Day(), h, m, 0, 0, ChinaLocation),
+			Price:     e.Price,
+			Vol:       e.Vol,
+			Num:       e.Num,
+			BuyOrSell: e.BuyOrSell,
+		})
+	}
+	it.start += len(list)
+	it.done = len(list) < MAX_TRANSACTION_COUNT
+	return true
+}
+
+// Page 当前页, 按时间升序
+func (it *TransactionIterator) Page() []Tick {
+	return it.page
+}
+
+func (it *TransactionIterator) Err() error {
+	return it.err
+}
+
+// DayTransactions 取 date 当天的全部分笔成交, 按时间升序, date 为零值时取当日.
+// 取完后用 VerifyTransactionVolume 与当天日线核对成交量, 不符时返回 ErrVolumeMismatch,
+// 核对失败时同时返回已取得的 ticks. 盘中取当日数据时日线仍在变化, 可能不符.
+func DayTransactions(ctx context.Context, hq ITdxHq, market uint16, code string, date time.Time) ([]Tick, error) {
+	var pages [][]Tick
+	it := NewTransactionIterator(hq, market, code, date)
+	for it.Next(ctx) {
+		pages = append(pages, it.Page())
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	var ticks []Tick
+	for i := len(pages) - 1; i >= 0; i-- {
+		ticks = append(ticks, pages[i]...)
+	}
+	if len(ticks) == 0 {
+		return ticks, nil
+	}
+	return ticks, VerifyTransactionVolume(ctx, hq, market, code, date, ticks)
+}
+
+// ErrVolumeMismatch 分笔成交量合计与日线成交量不符
+type ErrVolumeMismatch struct {
+	Ticks float64 // 分笔成交量合计(手)
+	Bar   float64 // 日线成交量(手)
+}
+
+func (e ErrVolumeMismatch) Error() string {
+	return fmt.Sprintf("transaction volume %.0f does not match daily bar volume %.0f", e.Ticks, e.Bar)
+}
+
+// VerifyTransactionVolume 检查 ticks 的成交量合计与 date 当天日线的成交量是否一致.
+// 股票、基金和债券的分笔与日线成交量都以手为单位; 指数和无法识别的证券没有可比的分笔成交量, 不核对.
+// date 当天没有日线(停牌或休市)时返回错误.
+func VerifyTransactionVolume(ctx context.Context, hq ITdxHq, market uint16, code string, date time.Time, ticks []Tick) error {
+	switch security.ClassifySecurity(uint8(market), SecurityElement{Code: code}) {
+	case security.SecurityIndex, security.SecurityUnknown:
+		return nil
+	}
+	if date.IsZero() {
+		date = time.Now().In(ChinaLocation)
+	}
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, ChinaLocation)
+	bars, err := BarsRange(ctx, hq, market, code, KLINE_TYPE_DAILY, day, day)
+	if err != nil {
+		return err
+	}
+	var bar *SecurityBarsElement
+	for i := range bars {
+		if bars[i].Year == day.Year() && bars[i].Month == int(day.Month()) && bars[i].Day == day.Day() {
+			bar = &bars[i]
+		}
+	}
+	if bar == nil {
+		return fmt.Errorf("no daily bar for %s on %s", code, day.Format("2006-01-02"))
+	}
+
+	var sum float64
+	for _, t := range ticks {
+		sum += float64(t.Vol)
+	}
+	if math.Abs(sum-bar.Vol) > bar.Vol*TRANSACTION_VOLUME_TOLERANCE {
+		return ErrVolumeMismatch{Ticks: sum, Bar: bar.Vol}
+	}
+	return nil
+}
+
+func yyyymmdd(t time.Time) uint32 {
+	return uint32(t.Year()*10000 + int(t.Month())*100 + t.Day())
+}
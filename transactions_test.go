@@ -0,0 +1,142 @@
+package gotdx
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	. "gotdx/imsg"
+	"math"
+	"testing"
+	"time"
+)
+
+// historyTicks 模拟历史分笔, 第 i 笔的时间为 09:30 后第 i/100 分钟, 成交量为 1 手
+func historyTicks(n int) func([]byte) []byte {
+	return func(req []byte) []byte {
+		start := int(binary.LittleEndian.Uint16(req[12:14]))
+		count := int(binary.LittleEndian.Uint16(req[14:16]))
+		hi := n - start
+		if hi < 0 {
+			hi = 0
+		}
+		lo := hi - count
+		if lo < 0 {
+			lo = 0
+		}
+		buf := new(bytes.Buffer)
+		binary.Write(buf, binary.LittleEndian, uint16(hi-lo))
+		buf.Write(make([]byte, 4))
+		for i := lo; i < hi; i++ {
+			binary.Write(buf, binary.LittleEndian, uint16(570+i/100))
+			if i == lo {
+				putPrice(buf, 1000)
+			} else {
+				putPrice(buf, 0)
+			}
+			putPrice(buf, 1)
+			putPrice(buf, i%2)
+			putPrice(buf, 0)
+		}
+		return buf.Bytes()
+	}
+}
+
+// oneDailyBar 返回一根日期为 date, 成交量为 vol 的日K线
+func oneDailyBar(date uint32, vol float32) func([]byte) []byte {
+	return func([]byte) []byte {
+		buf := new(bytes.Buffer)
+		binary.Write(buf, binary.LittleEndian, uint16(1))
+		binary.Write(buf, binary.LittleEndian, date)
+		for j := 0; j < 4; j++ {
+			putPrice(buf, 0)
+		}
+		binary.Write(buf, binary.LittleEndian, math.Float32bits(vol))
+		binary.Write(buf, binary.LittleEndian, math.Float32bits(0))
+		return buf.Bytes()
+	}
+}
+
+func TestDayTransactions(t *testing.T) {
+	hq, srv := newMockHq(t)
+	const n = 4500
+	srv.Handle(KMSG_HISTORYTRANSACTIONDATA, historyTicks(n))
+	srv.Handle(KMSG_INDEXBARS, oneDailyBar(20200818, n))
+
+	date := time.Date(2020, 8, 18, 0, 0, 0, 0, ChinaLocation)
+	it := NewTransactionIterator(hq, MARKET_SH, "600000", date)
+	var pages []int
+	for it.Next(context.Background()) {
+		pages = append(pages, len(it.Page()))
+	}
+	if it.Err() != nil {
+		t.Fatal(it.Err())
+	}
+	if len(pages) != 3 || pages[0] != MAX_TRANSACTION_COUNT || pages[2] != 500 {
+		t.Fatalf("pages = %v", pages)
+	}
+
+	ticks, err := DayTransactions(context.Background(), hq, MARKET_SH, "600000", date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ticks) != n {
+		t.Fatalf("got %d ticks, want %d", len(ticks), n)
+	}
+	first := time.Date(2020, 8, 18, 9, 30, 0, 0, ChinaLocation)
+	if !ticks[0].Time.Equal(first) {
+		t.Errorf("first tick at %v, want %v", ticks[0].Time, first)
+	}
+	if want := first.Add((n - 1) / 100 * time.Minute); !ticks[n-1].Time.Equal(want) {
+		t.Errorf("last tick at %v, want %v", ticks[n-1].Time, want)
+	}
+	for i := 1; i < len(ticks); i++ {
+		if ticks[i].Time.Before(ticks[i-1].Time) {
+			t.Fatalf("tick %d out of order", i)
+		}
+	}
+}
+
+func TestDayTransactions_VolumeMismatch(t *testing.T) {
+	hq, srv := newMockHq(t)
+	srv.Handle(KMSG_HISTORYTRANSACTIONDATA, historyTicks(2500))
+	srv.Handle(KMSG_INDEXBARS, oneDailyBar(20200818, 3000))
+
+	date := time.Date(2020, 8, 18, 0, 0, 0, 0, ChinaLocation)
+	ticks, err := DayTransactions(context.Background(), hq, MARKET_SH, "600000", date)
+	var mismatch ErrVolumeMismatch
+	if !errors.As(err, &mismatch) || mismatch.Ticks != 2500 || mismatch.Bar != 3000 {
+		t.Fatalf("err = %v, want ErrVolumeMismatch", err)
+	}
+	if len(ticks) != 2500 {
+		t.Errorf("got %d ticks with the mismatch, want 2500", len(ticks))
+	}
+}
+
+func TestVerifyTransactionVolume(t *testing.T) {
+	hq, srv := newMockHq(t)
+	date := time.Date(2020, 8, 18, 0, 0, 0, 0, ChinaLocation)
+	ticks := []Tick{{Vol: 300}, {Vol: 200}}
+
+	srv.Handle(KMSG_INDEXBARS, oneDailyBar(20200818, 500))
+	if err := VerifyTransactionVolume(context.Background(), hq, MARKET_SH, "600000", date, ticks); err != nil {
+		t.Error(err)
+	}
+
+	// 分笔和日线都以手为单位, 相差 100 倍不能通过
+	for _, vol := range []float32{600, 50000} {
+		srv.Handle(KMSG_INDEXBARS, oneDailyBar(20200818, vol))
+		err := VerifyTransactionVolume(context.Background(), hq, MARKET_SH, "600000", date, ticks)
+		var mismatch ErrVolumeMismatch
+		if !errors.As(err, &mismatch) || mismatch.Ticks != 500 || mismatch.Bar != float64(vol) {
+			t.Errorf("bar volume %v: err = %v, want ErrVolumeMismatch", vol, err)
+		}
+	}
+
+	// 当天停牌, 不能用下一个交易日的日线核对
+	srv.Handle(KMSG_INDEXBARS, oneDailyBar(20200819, 500))
+	var mismatch ErrVolumeMismatch
+	if err := VerifyTransactionVolume(context.Background(), hq, MARKET_SH, "600000", date, ticks); err == nil || errors.As(err, &mismatch) {
+		t.Errorf("err = %v, want missing daily bar", err)
+	}
+}
@@ -0,0 +1,116 @@
+package gotdx
+
+import (
+	"context"
+	. "gotdx/imsg"
+	"gotdx/security"
+	"sort"
+	"strings"
+)
+
+const SECURITY_LIST_PAGE = 1000 // 服务器每次返回的证券数量
+
+// SecurityType 证券类别, 见 security.SecurityType
+type SecurityType = security.SecurityType
+
+const (
+	SecurityUnknown     = security.SecurityUnknown
+	SecurityAShare      = security.SecurityAShare
+	SecurityBShare      = security.SecurityBShare
+	SecurityIndex       = security.SecurityIndex
+	SecurityETF         = security.SecurityETF
+	SecurityLOF         = security.SecurityLOF
+	SecurityFund        = security.SecurityFund
+	SecurityBond        = security.SecurityBond
+	SecurityConvertible = security.SecurityConvertible
+	SecurityRepo        = security.SecurityRepo
+)
+
+// ClassifySecurity 判断证券类别, 见 security.ClassifySecurity
+func ClassifySecurity(market uint8, e SecurityElement) SecurityType {
+	return security.ClassifySecurity(market, e)
+}
+
+// LimitRatio 涨跌幅限制比例, 见 security.LimitRatio
+func LimitRatio(market uint8, e SecurityElement) float64 {
+	return security.LimitRatio(market, e)
+}
+
+// SecurityKey 证券键
+type SecurityKey = security.SecurityKey
+
+// Security 带市场和类别的证券
+type Security struct {
+	Market uint8
+	Type   SecurityType
+	SecurityElement
+}
+
+// SecurityUniverse 沪深两市的全部证券
+type SecurityUniverse struct {
+	Securities map[SecurityKey]Security
+}
+
+// LoadSecurityUniverse 取沪深两市的全部证券并分类
+func LoadSecurityUniverse(ctx context.Context, hq ITdxHq) (*SecurityUniverse, error) {
+	u := &SecurityUniverse{Securities: make(map[SecurityKey]Security)}
+	for _, market := range []uint8{MARKET_SZ, MARKET_SH} {
+		list, err := SecurityListAll(ctx, hq, market)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range list {
+			e.Code = strings.TrimRight(e.Code, "\x00 ")
+			u.Securities[SecurityKey{Market: market, Code: e.Code}] = Security{
+				Market:          market,
+				Type:            ClassifySecurity(market, e),
+				SecurityElement: e,
+			}
+		}
+	}
+	return u, nil
+}
+
+// SecurityListAll 按 SecurityCount 分页取市场 market 的全部证券
+func SecurityListAll(ctx context.Context, hq ITdxHq, market uint8) ([]SecurityElement, error) {
+	count, err := hq.SecurityCount(ctx, TDXSecurityCountRequest{Market: int32(market)})
+	if err != nil {
+		return nil, err
+	}
+	list := make([]SecurityElement, 0, count.Count)
+	for start := 0; start < int(count.Count); {
+		rsp, err := hq.SecurityList(ctx, TDXSecurityListRequest{Market: uint16(market), Start: uint16(start)})
+		if err != nil {
+			return nil, err
+		}
+		if len(rsp.List) == 0 {
+			break
+		}
+		list = append(list, rsp.List...)
+		start += len(rsp.List)
+	}
+	return list, nil
+}
+
+// Security 查找证券
+func (u *SecurityUniverse) Security(market uint8, code string) (Security, bool) {
+	s, ok := u.Securities[SecurityKey{Market: market, Code: code}]
+	return s, ok
+}
+
+// OfType 类别为 t 的全部证券, 按市场和代码排序
+func (u *SecurityUniverse) OfType(t SecurityType) []Security {
+	var list []Security
+	for _, s := range u.Securities {
+		if s.Type == t {
+			list = append(list, s)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Market != list[j].Market {
+			return list[i].Market < list[j].Market
+		}
+		return list[i].Code < list[j].Code
+	})
+	return list
+}
@@ -0,0 +1,69 @@
+package gotdx
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	. "gotdx/imsg"
+	"testing"
+)
+
+// securityList 返回 n 只证券, 代码为 prefix 加序号, 每页最多 SECURITY_LIST_PAGE 只
+func securityList(prefix string, n int) func([]byte) []byte {
+	return func(req []byte) []byte {
+		start := int(binary.LittleEndian.Uint16(req[2:4]))
+		end := start + SECURITY_LIST_PAGE
+		if end > n {
+			end = n
+		}
+		buf := new(bytes.Buffer)
+		binary.Write(buf, binary.LittleEndian, uint16(end-start))
+		for i := start; i < end; i++ {
+			ele := make([]byte, 29)
+			copy(ele, fmt.Sprintf("%s%03d", prefix, i%1000))
+			copy(ele[6:], []byte{100, 0})
+			buf.Write(ele)
+		}
+		return buf.Bytes()
+	}
+}
+
+func TestLoadSecurityUniverse(t *testing.T) {
+	hq, srv := newMockHq(t)
+	srv.Handle(KMSG_SECURITYCOUNT, func(req []byte) []byte {
+		b := make([]byte, 2)
+		binary.LittleEndian.PutUint16(b, 1000)
+		if binary.LittleEndian.Uint16(req) == MARKET_SZ {
+			binary.LittleEndian.PutUint16(b, 1500)
+		}
+		return b
+	})
+	srv.Handle(KMSG_SECURITYLIST, func(req []byte) []byte {
+		if binary.LittleEndian.Uint16(req) == MARKET_SZ {
+			start := binary.LittleEndian.Uint16(req[2:4])
+			if start < 1000 {
+				return securityList("000", 1000)(req)
+			}
+			return securityList("399", 1500)(req)
+		}
+		return securityList("600", 1000)(req)
+	})
+
+	u, err := LoadSecurityUniverse(context.Background(), hq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(u.Securities) != 2500 {
+		t.Fatalf("got %d securities, want 2500", len(u.Securities))
+	}
+	if n := len(u.OfType(SecurityAShare)); n != 2000 {
+		t.Errorf("got %d A shares, want 2000", n)
+	}
+	if n := len(u.OfType(SecurityIndex)); n != 500 {
+		t.Errorf("got %d indexes, want 500", n)
+	}
+	if s, ok := u.Security(MARKET_SH, "600123"); !ok || s.Type != SecurityAShare || s.VolUnit != 100 {
+		t.Errorf("Security(600123) = %+v, %v", s, ok)
+	}
+}